@@ -0,0 +1,361 @@
+package lite
+
+import (
+	"context"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Identity is the identity proven by the credentials of a request, see Context.Identity
+type Identity struct {
+	Scheme  string         // Name of the security scheme which authenticated the request
+	Subject string         // Authenticated subject, e.g. the sub claim of a JWT or the user of HTTP basic
+	Scopes  []string       // Scopes granted to the credentials, checked against the scopes required by the route
+	Claims  map[string]any // Claims of the credentials, e.g. the claims of a JWT
+}
+
+// Authenticator verifies the credentials of a security scheme, see App.AddAuthenticator
+type Authenticator interface {
+	// Authenticate verifies the credentials sent in the isauth field of a request,
+	// e.g. the value of its Authorization header, and returns the identity they prove.
+	// An errors.HTTPError is returned as is, other errors are returned as 401 Unauthorized.
+	Authenticate(ctx context.Context, credentials string) (Identity, error)
+}
+
+// AddAuthenticator enforces a security scheme with an Authenticator.
+// The requests of the routes whose request struct has an isauth field of this scheme are rejected
+// with 401 Unauthorized before the handler is called, unless the Authenticator accepts their credentials.
+// When a request struct has several isauth fields, one of them must be accepted.
+// The security requirements of the routes (see Route.Security and Group.Security) are enforced too.
+// The security scheme is named by the name option of the isauth field:
+//
+//	Token   string `lite:"header=Authorization,isauth,scheme=bearer,name=bearerAuth"` // HTTP bearer (default) or basic
+//	APIKey  string `lite:"header=X-API-Key,isauth,scheme=apiKey"`                     // API key in a header
+//	APIKey  string `lite:"query=api_key,isauth"`                                      // API key in the query
+//	Session string `lite:"cookie=session,isauth"`                                     // API key in a cookie
+//	Token   string `lite:"header=Authorization,isauth,scheme=oauth2,name=oauth,scopes=read write"`
+//
+// OAuth2 and OpenID Connect schemes, whose bearer tokens are verified by the Authenticator, must be declared
+// with App.AddSecurityScheme. Requests whose identity lacks one of the scopes required by the route
+// are rejected with 403 Forbidden. The requests of a security scheme without Authenticator are rejected
+// with 500 Internal Server Error.
+func (s *App) AddAuthenticator(name string, authenticator Authenticator) *App {
+	s.authenticators[name] = authenticator
+
+	return s
+}
+
+// BasicAuthenticator verifies the username and password of the HTTP basic scheme
+type BasicAuthenticator func(ctx context.Context, username, password string) (Identity, error)
+
+func (a BasicAuthenticator) Authenticate(ctx context.Context, credentials string) (Identity, error) {
+	encoded, ok := cutAuthScheme(credentials, "Basic")
+	if !ok {
+		return Identity{}, fmt.Errorf("expected basic credentials")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid basic credentials")
+	}
+
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return Identity{}, fmt.Errorf("invalid basic credentials")
+	}
+
+	identity, err := a(ctx, username, password)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if identity.Subject == "" {
+		identity.Subject = username
+	}
+
+	return identity, nil
+}
+
+// APIKeyAuthenticator verifies an API key sent in a header, a query parameter or a cookie
+type APIKeyAuthenticator func(ctx context.Context, key string) (Identity, error)
+
+func (a APIKeyAuthenticator) Authenticate(ctx context.Context, credentials string) (Identity, error) {
+	return a(ctx, credentials)
+}
+
+// cutAuthScheme returns the credentials of an Authorization header value of the given scheme, e.g. Bearer
+func cutAuthScheme(value, scheme string) (string, bool) {
+	prefix, credentials, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(prefix, scheme) {
+		return "", false
+	}
+
+	return strings.TrimSpace(credentials), true
+}
+
+// NewOAuth2SecurityScheme returns an OAuth2 security scheme with its flows, see App.AddSecurityScheme
+//
+//	app.AddSecurityScheme("oauth", lite.NewOAuth2SecurityScheme(openapi3.OAuthFlows{
+//		ClientCredentials: &openapi3.OAuthFlow{
+//			TokenURL: "https://example.com/oauth/token",
+//			Scopes:   map[string]string{"read": "Read the resources"},
+//		},
+//	}))
+func NewOAuth2SecurityScheme(flows openapi3.OAuthFlows) *openapi3.SecurityScheme {
+	return &openapi3.SecurityScheme{Type: "oauth2", Flows: &flows}
+}
+
+// securitySchemeOf returns the name and the security scheme declared by an isauth field, see App.AddAuthenticator
+func securitySchemeOf(in, key string, tagMap map[string]string) (string, *openapi3.SecurityScheme) {
+	scheme := &openapi3.SecurityScheme{Type: "apiKey", In: in, Name: key}
+	name := key
+
+	if in == "header" && !strings.EqualFold(tagMap["scheme"], "apiKey") {
+		scheme = openapi3.NewSecurityScheme()
+		scheme.Type = "http"
+		scheme.Scheme = "bearer"
+		name = "Authorization"
+
+		switch valueScheme, ok := tagMap["scheme"]; {
+		case strings.EqualFold(valueScheme, "oauth2"):
+			scheme = &openapi3.SecurityScheme{Type: "oauth2"}
+		case strings.EqualFold(valueScheme, "openIdConnect"):
+			scheme = &openapi3.SecurityScheme{Type: "openIdConnect"}
+		case ok:
+			scheme.Scheme = valueScheme
+		}
+	}
+
+	if valueName, ok := tagMap["name"]; ok {
+		name = valueName
+	}
+
+	return name, scheme
+}
+
+// securityField is an isauth field of a request, holding the credentials of a security scheme
+type securityField struct {
+	scheme string // name of the security scheme
+	in     string // header, query or cookie
+	key    string // name of the header, query parameter or cookie
+	http   string // scheme of the http security schemes, e.g. bearer
+}
+
+// newSecurityField returns the field holding the credentials of a security scheme, in the header key
+// unless the scheme is an API key
+func newSecurityField(name, key string, scheme *openapi3.SecurityScheme) securityField {
+	field := securityField{scheme: name, in: "header", key: key}
+
+	switch scheme.Type {
+	case "apiKey":
+		field.in, field.key = scheme.In, scheme.Name
+	case "http":
+		field.http = strings.ToLower(scheme.Scheme)
+	default:
+		// OAuth2 and OpenID Connect access tokens are bearer tokens
+		field.http = "bearer"
+	}
+
+	return field
+}
+
+func (f securityField) credentials(ctx *fasthttp.RequestCtx) string {
+	switch f.in {
+	case "header":
+		return string(ctx.Request.Header.Peek(f.key))
+	case "query":
+		return string(ctx.QueryArgs().Peek(f.key))
+	case "cookie":
+		return string(ctx.Request.Header.Cookie(f.key))
+	default:
+		return ""
+	}
+}
+
+func hasSecurityField(fields []securityField, scheme string) bool {
+	for _, field := range fields {
+		if field.scheme == scheme {
+			return true
+		}
+	}
+
+	return false
+}
+
+var identityKey = NewKey[Identity]("lite.identity")
+
+// authenticate verifies the credentials of the security fields of a request with the authenticators of the App.
+// The identity of the first accepted credentials is attached to the request.
+// Requests of a security scheme without authenticator are rejected with 500 Internal Server Error,
+// so that a route is never served unauthenticated because of a missing App.AddAuthenticator.
+func authenticate(c *fiber.Ctx, app *App, fields []securityField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var authErr error
+
+	for _, field := range fields {
+		authenticator, ok := app.authenticators[field.scheme]
+		if !ok {
+			slog.ErrorContext(c.UserContext(), "no authenticator registered for security scheme", slog.String("scheme", field.scheme))
+
+			return errors.NewInternalServerError()
+		}
+
+		credentials := field.credentials(c.Context())
+		if credentials == "" {
+			authErr = firstError(authErr, fmt.Errorf("missing credentials"))
+
+			continue
+		}
+
+		identity, err := authenticator.Authenticate(c.UserContext(), credentials)
+		if err != nil {
+			authErr = firstError(authErr, err)
+
+			continue
+		}
+
+		identity.Scheme = field.scheme
+		c.Context().SetUserValue(identityKey, identity)
+
+		return nil
+	}
+
+	var httpError errors.HTTPError
+	if stderrors.As(authErr, &httpError) {
+		return httpError
+	}
+
+	for _, field := range fields {
+		if field.http != "" {
+			c.Set(HeaderWWWAuthenticate, strings.ToUpper(field.http[:1])+field.http[1:])
+
+			break
+		}
+	}
+
+	return errors.NewUnauthorizedError(authErr.Error())
+}
+
+func firstError(err, next error) error {
+	if err != nil {
+		return err
+	}
+
+	return next
+}
+
+// authenticate authenticates the request of a route, unless a Middleware already did, then checks the scopes
+// its security requirements require from the authenticated scheme
+func (r *registeredRoute) authenticate(c *fiber.Ctx, app *App) error {
+	fields := r.securityFields(app)
+
+	identity, ok := c.Context().UserValue(identityKey).(Identity)
+	if !ok || !hasSecurityField(fields, identity.Scheme) {
+		if err := authenticate(c, app, fields); err != nil {
+			return err
+		}
+
+		if identity, ok = c.Context().UserValue(identityKey).(Identity); !ok {
+			return nil
+		}
+	}
+
+	return authorize(c, identity, r.operation.Security, fields)
+}
+
+// securityFields returns the security fields of the request struct of a route,
+// followed by those of the security schemes it requires which no field holds
+func (r *registeredRoute) securityFields(app *App) []securityField {
+	r.securityOnce.Do(func() {
+		if r.binder != nil {
+			r.security = append(r.security, r.binder.security...)
+		}
+
+		if r.operation == nil || r.operation.Security == nil {
+			return
+		}
+
+		for _, requirement := range *r.operation.Security {
+			names := make([]string, 0, len(requirement))
+			for name := range requirement {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			for _, name := range names {
+				ref := app.OpenAPISpec.Components.SecuritySchemes[name]
+				if hasSecurityField(r.security, name) || ref == nil || ref.Value == nil {
+					continue
+				}
+
+				r.security = append(r.security, newSecurityField(name, HeaderAuthorization, ref.Value))
+			}
+		}
+	})
+
+	return r.security
+}
+
+// authorize checks that the identity has every scope of one of the security requirements of its scheme
+func authorize(c *fiber.Ctx, identity Identity, requirements *openapi3.SecurityRequirements, fields []securityField) error {
+	if requirements == nil {
+		return nil
+	}
+
+	var missing []string
+
+	for _, requirement := range *requirements {
+		scopes, ok := requirement[identity.Scheme]
+		if !ok {
+			continue
+		}
+
+		missing = missingScopes(identity.Scopes, scopes)
+		if len(missing) == 0 {
+			return nil
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	for _, field := range fields {
+		if field.scheme == identity.Scheme && field.http == "bearer" {
+			c.Set(HeaderWWWAuthenticate, fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(missing, " ")))
+		}
+	}
+
+	return errors.NewForbiddenError("insufficient scope: " + strings.Join(missing, " "))
+}
+
+func missingScopes(granted, required []string) []string {
+	var missing []string
+
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// Identity returns the identity proven by the credentials of the request, see App.AddAuthenticator
+func (c *ContextNoRequest) Identity() (Identity, bool) {
+	return identityKey.Get(c)
+}
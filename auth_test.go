@@ -0,0 +1,252 @@
+package lite
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type basicAuthRequest struct {
+	Login string `lite:"header=Authorization,isauth,scheme=basic,name=basicAuth"`
+}
+
+type apiKeyRequest struct {
+	HeaderKey string `lite:"header=X-API-Key,isauth,scheme=apiKey,name=headerKey"`
+	QueryKey  string `lite:"query=api_key,isauth,name=queryKey"`
+	CookieKey string `lite:"cookie=api_key,isauth,name=cookieKey"`
+}
+
+func identityHandler[Request any](c *ContextWithRequest[Request]) (string, error) {
+	identity, ok := c.Identity()
+	if !ok {
+		return "anonymous", nil
+	}
+
+	return identity.Scheme + ":" + identity.Subject, nil
+}
+
+func TestAuthenticator_Basic(t *testing.T) {
+	app := New()
+
+	app.AddAuthenticator("basicAuth", BasicAuthenticator(func(_ context.Context, username, password string) (Identity, error) {
+		if password != "secret" {
+			return Identity{}, errors.NewUnauthorizedError("invalid password")
+		}
+
+		return Identity{}, nil
+	}))
+
+	Get(app, "/me", identityHandler[basicAuthRequest])
+
+	basic := func(credentials string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{name: "valid", authorization: basic("john:secret"), status: 200, body: "basicAuth:john"},
+		{name: "missing", status: 401},
+		{name: "invalid password", authorization: basic("john:wrong"), status: 401, body: `"message":"invalid password"`},
+		{name: "not basic", authorization: "Bearer token", status: 401, body: `"message":"expected basic credentials"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.body)
+
+			if tt.status == 401 && tt.name != "invalid password" {
+				assert.Equal(t, "Basic", resp.Header.Get(HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	app := New()
+
+	authenticator := APIKeyAuthenticator(func(_ context.Context, key string) (Identity, error) {
+		if key != "secret" {
+			return Identity{}, errors.NewUnauthorizedError("invalid API key")
+		}
+
+		return Identity{Subject: "service"}, nil
+	})
+
+	app.AddAuthenticator("headerKey", authenticator).
+		AddAuthenticator("queryKey", authenticator).
+		AddAuthenticator("cookieKey", authenticator)
+
+	Get(app, "/me", identityHandler[apiKeyRequest])
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("X-API-Key", "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "headerKey:service", string(body))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/me?api_key=secret", nil))
+	assert.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "queryKey:service", string(body))
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Cookie", "api_key=secret")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "cookieKey:service", string(body))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/me?api_key=wrong", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(HeaderWWWAuthenticate))
+
+	schemes := app.OpenAPISpec.Components.SecuritySchemes
+	assert.Equal(t, "header", schemes["headerKey"].Value.In)
+	assert.Equal(t, "X-API-Key", schemes["headerKey"].Value.Name)
+	assert.Equal(t, "query", schemes["queryKey"].Value.In)
+	assert.Equal(t, "cookie", schemes["cookieKey"].Value.In)
+	assert.Len(t, *app.OpenAPISpec.Paths.Find("/me").Get.Security, 3)
+}
+
+// a security scheme without authenticator fails closed
+func TestAuthenticator_NotRegistered(t *testing.T) {
+	app := New()
+
+	Get(app, "/me", identityHandler[basicAuthRequest])
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.SetBasicAuth("john", "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 500, resp.StatusCode)
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	app := New()
+
+	app.AddAuthenticator("basicAuth", BasicAuthenticator(func(context.Context, string, string) (Identity, error) {
+		return Identity{}, nil
+	}))
+
+	api := app.Group("/api").UseMiddleware(NewMiddleware(func(c *ContextWithRequest[basicAuthRequest]) error {
+		return c.Next()
+	}))
+
+	Get(api, "/me", identityHandler[struct{}])
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/me", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	req := httptest.NewRequest("GET", "/api/me", nil)
+	req.SetBasicAuth("john", "secret")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "basicAuth:john", string(body))
+}
+
+type oauthRequest struct {
+	Token string `lite:"header=Authorization,isauth,scheme=oauth2,name=oauth,scopes=read"`
+}
+
+func TestAuthenticator_Scopes(t *testing.T) {
+	app := New()
+
+	app.AddSecurityScheme("oauth", NewOAuth2SecurityScheme(openapi3.OAuthFlows{
+		ClientCredentials: &openapi3.OAuthFlow{
+			TokenURL: "https://example.com/oauth/token",
+			Scopes:   map[string]string{"read": "Read", "write": "Write", "admin": "Administrate"},
+		},
+	}))
+
+	app.AddAuthenticator("oauth", APIKeyAuthenticator(func(_ context.Context, token string) (Identity, error) {
+		scopes, ok := strings.CutPrefix(token, "Bearer ")
+		if !ok {
+			return Identity{}, errors.NewUnauthorizedError("expected bearer token")
+		}
+
+		return Identity{Subject: "john", Scopes: strings.Split(scopes, ",")}, nil
+	}))
+
+	admin := app.Group("/admin").Security("oauth", "admin")
+
+	Get(app, "/items", identityHandler[oauthRequest])
+	Post(app, "/items", identityHandler[oauthRequest]).Security("oauth", "write")
+	Get(admin, "/items", identityHandler[struct{}])
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		status        int
+	}{
+		{name: "granted", method: "GET", path: "/items", authorization: "Bearer read", status: 200},
+		{name: "missing scope", method: "GET", path: "/items", authorization: "Bearer write", status: 403},
+		{name: "route scopes", method: "POST", path: "/items", authorization: "Bearer read,write", status: 201},
+		{name: "missing route scope", method: "POST", path: "/items", authorization: "Bearer read", status: 403},
+		{name: "group scopes", method: "GET", path: "/admin/items", authorization: "Bearer admin", status: 200},
+		{name: "missing group scope", method: "GET", path: "/admin/items", authorization: "Bearer read", status: 403},
+		{name: "group unauthenticated", method: "GET", path: "/admin/items", status: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			if tt.status == 403 {
+				assert.Contains(t, resp.Header.Get(HeaderWWWAuthenticate), `Bearer error="insufficient_scope"`)
+			}
+		})
+	}
+
+	operation := app.OpenAPISpec.Paths.Find("/items").Post
+	assert.Equal(t, openapi3.SecurityRequirements{{"oauth": {"read", "write"}}}, *operation.Security)
+	assert.NotNil(t, operation.Responses.Value("403"))
+	assert.Equal(t, []string{"admin"}, (*app.OpenAPISpec.Paths.Find("/admin/items").Get.Security)[0]["oauth"])
+	assert.Equal(t, "oauth2", app.OpenAPISpec.Components.SecuritySchemes["oauth"].Value.Type)
+	assert.NotNil(t, app.OpenAPISpec.Components.SecuritySchemes["oauth"].Value.Flows.ClientCredentials)
+}
+
+func TestSecuritySchemeOf_Undeclared(t *testing.T) {
+	app := New()
+
+	assert.Panics(t, func() {
+		Get(app, "/items", identityHandler[oauthRequest])
+	})
+}
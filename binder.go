@@ -0,0 +1,447 @@
+package lite
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// binder binds the parameters and the body of a request to the fields of its struct.
+// It is compiled once per route from the lite tags of the struct, so that binding a request
+// neither parses the tags nor walks the struct again.
+type binder struct {
+	fields   []fieldBinder
+	security []securityField
+}
+
+// fieldBinder binds a parameter or the body of a request to a field, and validates it
+type fieldBinder struct {
+	index []int  // index of the field, through the untagged nested structs
+	in    string // path, query, header, cookie or body
+	name  string // name of the parameter
+	set   func(fieldVal reflect.Value, values []string) error
+	rules []validationRule
+	body  *bodyValidator // validator of the fields of the body, nil when they declare no rules
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var (
+	errUnsupportedKind = stderrors.New("unsupported kind")
+	errTooManyValues   = stderrors.New("too many values")
+)
+
+// routeParams returns the value of a path parameter of the request, false when it has none
+type routeParams func(name string) (string, bool)
+
+// boundParams records the parameters found in a request by a binder, so that the validation rules
+// of the parameters absent from the request are skipped while their explicit zero values are checked
+type boundParams map[string]bool
+
+func (p boundParams) add(in, name string) {
+	if p != nil {
+		p[in+"="+name] = true
+	}
+}
+
+func (p boundParams) has(in, name string) bool {
+	return p[in+"="+name]
+}
+
+// newBinder compiles the binder of a request struct. The fields of the bodies are named
+// by the struct tag of the codec of their content type.
+func newBinder(dstType reflect.Type, codecs codecRegistry) (*binder, error) {
+	b := &binder{}
+
+	if err := b.compile(dstType, codecs, nil); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *binder) compile(dstType reflect.Type, codecs codecRegistry, index []int) error {
+	for i := 0; i < dstType.NumField(); i++ {
+		field := dstType.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		tag := field.Tag.Get("lite")
+
+		if field.Type.Kind() == reflect.Struct && tag == "" {
+			if err := b.compile(field.Type, codecs, fieldIndex); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if tag == "" {
+			return fmt.Errorf("missing tag for field %s", field.Name)
+		}
+
+		tagMap := parseTag(tag)
+
+		rules, err := validationRules(field)
+		if err != nil {
+			return err
+		}
+
+		if tagMap["req"] == "body" {
+			structTag := getStructTag(codecs, bodyContentType(tagMap))
+
+			body, err := newBodyValidator(field.Type, structTag, map[reflect.Type]*bodyValidator{})
+			if err != nil {
+				return err
+			}
+
+			b.fields = append(b.fields, fieldBinder{index: fieldIndex, in: "body", name: "body", rules: rules, body: body})
+
+			continue
+		}
+
+		in, name := paramLocation(tagMap)
+		if in == "body" || name == "" {
+			continue
+		}
+
+		if _, isAuth := tagMap["isauth"]; isAuth && in != "path" {
+			scheme, securityScheme := securitySchemeOf(in, name, tagMap)
+			b.security = append(b.security, newSecurityField(scheme, name, securityScheme))
+		}
+
+		set, err := newSetter(field.Type, in, name, tagMap)
+		if err != nil {
+			return err
+		}
+
+		b.fields = append(b.fields, fieldBinder{index: fieldIndex, in: in, name: name, set: set, rules: rules})
+	}
+
+	return nil
+}
+
+// newSetter returns the function converting the values of a parameter to the type of its field
+func newSetter(fieldType reflect.Type, in, name string, tagMap map[string]string) (
+	func(fieldVal reflect.Value, values []string) error,
+	error,
+) {
+	if in == "cookie" && indirectType(fieldType) == cookieType {
+		return func(fieldVal reflect.Value, values []string) error {
+			for fieldVal.Kind() == reflect.Ptr {
+				if fieldVal.IsNil() {
+					fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+				}
+
+				fieldVal = fieldVal.Elem()
+			}
+
+			fieldVal.Set(reflect.ValueOf(http.Cookie{Name: name, Value: values[0]}))
+
+			return nil
+		}, nil
+	}
+
+	if isArrayParam(fieldType) {
+		style, err := newParamStyle(in, tagMap)
+		if err != nil {
+			return nil, err
+		}
+
+		convert := newSliceConverter(fieldType)
+
+		return func(fieldVal reflect.Value, values []string) error {
+			return convert(fieldVal, style.split(values))
+		}, nil
+	}
+
+	convert := newConverter(fieldType)
+
+	return func(fieldVal reflect.Value, values []string) error {
+		return convert(fieldVal, values[0])
+	}, nil
+}
+
+// converter converts the value of a parameter to its field
+type converter func(fieldVal reflect.Value, value string) error
+
+// newConverter returns the converter of a field type, resolved once when the binder is compiled.
+// The types no parameter converts to return their error when a request is bound.
+func newConverter(t reflect.Type) converter {
+	switch t.Kind() {
+	case reflect.Ptr:
+		convert := newConverter(t.Elem())
+
+		return func(fieldVal reflect.Value, value string) error {
+			if fieldVal.IsNil() {
+				fieldVal.Set(reflect.New(t.Elem()))
+			}
+
+			return convert(fieldVal.Elem(), value)
+		}
+	case reflect.String:
+		return func(fieldVal reflect.Value, value string) error {
+			fieldVal.SetString(value)
+
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+
+		return func(fieldVal reflect.Value, value string) error {
+			intValue, err := strconv.ParseInt(value, 10, bits)
+			if err != nil {
+				return err
+			}
+
+			fieldVal.SetInt(intValue)
+
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bits := t.Bits()
+
+		return func(fieldVal reflect.Value, value string) error {
+			uintValue, err := strconv.ParseUint(value, 10, bits)
+			if err != nil {
+				return err
+			}
+
+			fieldVal.SetUint(uintValue)
+
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+
+		return func(fieldVal reflect.Value, value string) error {
+			floatValue, err := strconv.ParseFloat(value, bits)
+			if err != nil {
+				return err
+			}
+
+			fieldVal.SetFloat(floatValue)
+
+			return nil
+		}
+	case reflect.Bool:
+		return func(fieldVal reflect.Value, value string) error {
+			boolValue, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+
+			fieldVal.SetBool(boolValue)
+
+			return nil
+		}
+	case reflect.Struct:
+		if t == timeType {
+			return func(fieldVal reflect.Value, value string) error {
+				timeValue, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					return err
+				}
+
+				fieldVal.Set(reflect.ValueOf(timeValue))
+
+				return nil
+			}
+		}
+
+		return func(fieldVal reflect.Value, value string) error {
+			return json.Unmarshal([]byte(value), fieldVal.Addr().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return func(fieldVal reflect.Value, value string) error {
+				fieldVal.SetBytes([]byte(value))
+
+				return nil
+			}
+		}
+
+		convert := newSliceConverter(t)
+
+		return func(fieldVal reflect.Value, value string) error {
+			return convert(fieldVal, []string{value})
+		}
+	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func,
+		reflect.Interface, reflect.Map, reflect.UnsafePointer:
+		fallthrough
+	default:
+		err := fmt.Errorf("%w %s", errUnsupportedKind, t.Kind())
+
+		return func(reflect.Value, string) error {
+			return err
+		}
+	}
+}
+
+// newSliceConverter returns the function converting the values of an array parameter
+// to the elements of its slice or array field, through pointers
+func newSliceConverter(t reflect.Type) func(fieldVal reflect.Value, values []string) error {
+	if t.Kind() == reflect.Ptr {
+		convert := newSliceConverter(t.Elem())
+
+		return func(fieldVal reflect.Value, values []string) error {
+			if fieldVal.IsNil() {
+				fieldVal.Set(reflect.New(t.Elem()))
+			}
+
+			return convert(fieldVal.Elem(), values)
+		}
+	}
+
+	convert := newConverter(t.Elem())
+
+	if t.Kind() == reflect.Array {
+		return func(fieldVal reflect.Value, values []string) error {
+			if len(values) > fieldVal.Len() {
+				return fmt.Errorf("%w for array of length %d", errTooManyValues, fieldVal.Len())
+			}
+
+			for i, value := range values {
+				if err := convert(fieldVal.Index(i), value); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	return func(fieldVal reflect.Value, values []string) error {
+		slice := reflect.MakeSlice(t, len(values), len(values))
+
+		for i, value := range values {
+			if err := convert(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+
+		fieldVal.Set(slice)
+
+		return nil
+	}
+}
+
+// bind binds the request to dstVal. Path parameters are skipped when params is nil.
+// The parameters found in the request are added to bound, unless it is nil.
+// A value that cannot be converted to its field is reported as a Bad Request HTTPError naming the parameter.
+func (b *binder) bind(
+	ctx *fasthttp.RequestCtx,
+	codecs codecRegistry,
+	dstVal reflect.Value,
+	params routeParams,
+	bound boundParams,
+) error {
+	for _, field := range b.fields {
+		fieldVal := dstVal.FieldByIndex(field.index)
+
+		var values []string
+
+		switch field.in {
+		case "body":
+			if err := deserializeBody(ctx, codecs, fieldVal); err != nil {
+				return err
+			}
+
+			continue
+		case "path":
+			if params == nil {
+				continue
+			}
+
+			value, ok := params(field.name)
+			if !ok {
+				return fmt.Errorf("missing path parameter: %s", field.name)
+			}
+
+			values = append(values, value)
+		case "query":
+			for _, value := range ctx.QueryArgs().PeekMulti(field.name) {
+				if len(value) > 0 {
+					values = append(values, string(value))
+				}
+			}
+		case "header":
+			for _, value := range ctx.Request.Header.PeekAll(field.name) {
+				if len(value) > 0 {
+					values = append(values, string(value))
+				}
+			}
+		case "cookie":
+			values = cookieValues(ctx, field.name)
+		}
+
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+
+		bound.add(field.in, field.name)
+
+		if err := field.set(fieldVal, values); err != nil {
+			if stderrors.Is(err, errUnsupportedKind) {
+				return err
+			}
+
+			httpErr := errors.NewBadRequestError("Invalid parameter")
+			httpErr.Errors = []errors.FieldError{{Field: field.name, In: field.in, Message: invalidParamMessage(fieldVal.Type(), err)}}
+
+			return httpErr
+		}
+	}
+
+	return nil
+}
+
+// invalidParamMessage describes why the value of a parameter cannot be converted to the type of its field
+func invalidParamMessage(t reflect.Type, err error) string {
+	var numErr *strconv.NumError
+
+	switch {
+	case stderrors.Is(err, errTooManyValues):
+		return err.Error()
+	case stderrors.As(err, &numErr) && stderrors.Is(numErr.Err, strconv.ErrRange):
+		return "is out of range"
+	}
+
+	t = indirectType(t)
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8 {
+		t = indirectType(t.Elem())
+	}
+
+	switch {
+	case t == timeType:
+		return "must be a date-time in RFC 3339 format"
+	case t.Kind() == reflect.Bool:
+		return "must be a boolean"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "must be a number"
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		return "must be a non-negative integer"
+	case isNumberKind(t.Kind()):
+		return "must be an integer"
+	default:
+		return "must be valid JSON"
+	}
+}
+
+// mapParams returns the path parameters held by a map, nil when there are none
+func mapParams(params map[string]string) routeParams {
+	if params == nil {
+		return nil
+	}
+
+	return func(name string) (string, bool) {
+		value, ok := params[name]
+
+		return value, ok
+	}
+}
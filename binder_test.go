@@ -0,0 +1,269 @@
+package lite
+
+import (
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type binderParams struct {
+	ID   uint64 `lite:"path=id"`
+	Name string `lite:"path=name"`
+}
+
+type binderRequest struct {
+	Params binderParams
+	Tags   []string `lite:"query=tags,explode=false"`
+	Limit  *int     `lite:"query=limit"`
+	Token  string   `lite:"header=X-Token"`
+	Body   reqBody  `lite:"req=body"`
+}
+
+type binderResponse struct {
+	ID    uint64   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Limit *int     `json:"limit"`
+	Token string   `json:"token"`
+	Body  float64  `json:"body"`
+}
+
+func TestNewBinder(t *testing.T) {
+	b, err := newBinder(reflect.TypeOf(binderRequest{}), defaultCodecs())
+	assert.NoError(t, err)
+
+	assert.Len(t, b.fields, 6)
+	assert.Equal(t, []int{0, 1}, b.fields[1].index)
+	assert.Equal(t, "name", b.fields[1].name)
+	assert.Equal(t, "body", b.fields[5].in)
+
+	_, err = newBinder(reflect.TypeOf(struct {
+		ID int
+	}{}), defaultCodecs())
+	assert.EqualError(t, err, "missing tag for field ID")
+
+	_, err = newBinder(reflect.TypeOf(struct {
+		IDs []int `lite:"path=ids,style=form"`
+	}{}), defaultCodecs())
+	assert.Error(t, err)
+
+	_, err = newBinder(reflect.TypeOf(struct {
+		Limit int `lite:"query=limit" validate:"min=a"`
+	}{}), defaultCodecs())
+	assert.Error(t, err)
+}
+
+func TestBinder_Route(t *testing.T) {
+	app := New()
+
+	Post(app, "/foo/:id/:name", func(c *ContextWithRequest[binderRequest]) (binderResponse, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return binderResponse{}, err
+		}
+
+		return binderResponse{
+			ID:    req.Params.ID,
+			Name:  req.Params.Name,
+			Tags:  req.Tags,
+			Limit: req.Limit,
+			Token: req.Token,
+			Body:  req.Body.ID,
+		}, nil
+	})
+
+	assert.NotNil(t, app.routes[0].binder)
+
+	req := httptest.NewRequest("POST", "/foo/42/john%20doe?tags=a,b", strings.NewReader(`{"id":1.5}`))
+	req.Header.Set("X-Token", "secret")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 201, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id":42,"name":"john doe","tags":["a","b"],"limit":null,"token":"secret","body":1.5}`, string(body))
+}
+
+// untagged fields are rejected when the binder is compiled, so that their route is not registered
+func TestBinder_UntaggedField(t *testing.T) {
+	type request struct {
+		Name  string `lite:"query=name"`
+		Cache map[string]string
+	}
+
+	_, err := newBinder(reflect.TypeOf(request{}), defaultCodecs())
+	assert.EqualError(t, err, "missing tag for field Cache")
+
+	assert.Panics(t, func() {
+		Get(New(), "/foo", func(c *ContextWithRequest[request]) (string, error) {
+			return "", nil
+		})
+	})
+}
+
+func TestNewConverter(t *testing.T) {
+	type values struct {
+		Int     int8
+		Uint    *uint16
+		Float   float32
+		Bool    bool
+		String  string
+		Time    time.Time
+		Bytes   []byte
+		Ints    []int
+		Array   [2]*int
+		Struct  struct{ A int }
+		Complex complex64
+	}
+
+	var v values
+
+	val := reflect.ValueOf(&v).Elem()
+
+	for field, value := range map[string]string{
+		"Int":    "-8",
+		"Uint":   "16",
+		"Float":  "1.5",
+		"Bool":   "true",
+		"String": "abc",
+		"Time":   "2024-05-01T10:00:00Z",
+		"Bytes":  "raw",
+		"Ints":   "3",
+		"Struct": `{"A":1}`,
+	} {
+		fieldVal := val.FieldByName(field)
+		assert.NoError(t, newConverter(fieldVal.Type())(fieldVal, value), field)
+	}
+
+	assert.NoError(t, newSliceConverter(val.FieldByName("Array").Type())(val.FieldByName("Array"), []string{"1", "2"}))
+
+	uint16Value, one, two := uint16(16), 1, 2
+
+	assert.Equal(t, values{
+		Int:    -8,
+		Uint:   &uint16Value,
+		Float:  1.5,
+		Bool:   true,
+		String: "abc",
+		Time:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Bytes:  []byte("raw"),
+		Ints:   []int{3},
+		Array:  [2]*int{&one, &two},
+		Struct: struct{ A int }{A: 1},
+	}, v)
+
+	assert.Error(t, newConverter(reflect.TypeOf(int8(0)))(val.FieldByName("Int"), "300"))
+	assert.Error(t, newSliceConverter(val.FieldByName("Array").Type())(val.FieldByName("Array"), []string{"1", "2", "3"}))
+	assert.EqualError(t, newConverter(val.FieldByName("Complex").Type())(val.FieldByName("Complex"), "1"), "unsupported kind complex64")
+}
+
+func TestExtractParams(t *testing.T) {
+	assert.Equal(t, map[string]string{"id": "42", "name": "john"}, extractParams("/foo/:id/:name", "/foo/42/john"))
+	assert.Nil(t, extractParams("/foo", "/foo"))
+	assert.Nil(t, extractParams("/foo/:id", "/bar/42"))
+	assert.Nil(t, extractParams("/foo/:id", "/foo/"))
+	assert.Nil(t, extractParams("/foo/:id", "/foo/42/bar"))
+}
+
+func newBenchmarkRequest() *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI("/foo/42/john?tags=a,b&limit=10")
+	ctx.Request.Header.Set("X-Token", "secret")
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString(`{"id":1.5}`)
+
+	return ctx
+}
+
+// BenchmarkBinder_Compiled binds a request with the binder compiled when the route is registered
+func BenchmarkBinder_Compiled(b *testing.B) {
+	ctx := newBenchmarkRequest()
+	codecs := defaultCodecs()
+	params := map[string]string{"id": "42", "name": "john"}
+
+	binder, err := newBinder(reflect.TypeOf(binderRequest{}), defaultCodecs())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var req binderRequest
+
+		if err := binder.bind(ctx, codecs, reflect.ValueOf(&req).Elem(), mapParams(params), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkBinder_PerRequest compiles the binder and matches the path on every request,
+// as contexts created outside of a route handler do
+func BenchmarkBinder_PerRequest(b *testing.B) {
+	ctx := newBenchmarkRequest()
+	codecs := defaultCodecs()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var req binderRequest
+
+		params := extractParams("/foo/:id/:name", string(ctx.Path()))
+
+		if err := deserialize(ctx, codecs, reflect.ValueOf(&req).Elem(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type validatedBinderBody struct {
+	ID float64 `json:"id" validate:"min=0"`
+}
+
+type validatedBinderRequest struct {
+	Params binderParams
+	Tags   []string            `lite:"query=tags,explode=false" validate:"max=5"`
+	Limit  *int                `lite:"query=limit"              validate:"min=1,max=100"`
+	Token  string              `lite:"header=X-Token"           validate:"required"`
+	Body   validatedBinderBody `lite:"req=body"`
+}
+
+// BenchmarkBinder_Handler serves a request through the Fiber handler of a route, binding and validating it
+func BenchmarkBinder_Handler(b *testing.B) {
+	app := New()
+
+	Post(app, "/foo/:id/:name", func(c *ContextWithRequest[validatedBinderRequest]) (binderResponse, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return binderResponse{}, err
+		}
+
+		return binderResponse{ID: req.Params.ID, Name: req.Params.Name}, nil
+	})
+
+	handler := app.Handler()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ctx := newBenchmarkRequest()
+
+		handler(ctx)
+
+		if ctx.Response.StatusCode() != 201 {
+			b.Fatalf("unexpected status code %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
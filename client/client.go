@@ -0,0 +1,209 @@
+// Package client calls the routes of a lite server from Go.
+//
+// It is the runtime of the clients generated by the clientgen package, but it can also be used directly.
+// Requests are described by the same structs as on the server side, with the `lite` struct tag:
+//
+//	type GetUserReq struct {
+//		ID     uint64 `lite:"path=id"`
+//		Fields string `lite:"query=fields"`
+//		Tenant string `lite:"header=X-Tenant"`
+//		Body   User   `lite:"req=body,application/json"`
+//	}
+//
+//	user, err := client.Do[User](ctx, c, http.MethodGet, "/users/:id", GetUserReq{ID: 1})
+//
+// Error responses are returned as errors.HTTPError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-lite/lite/errors"
+)
+
+// Client sends requests to a lite server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to send the requests, http.DefaultClient by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader sets a header sent with every request, e.g. an Authorization header
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// New returns a Client of the server at baseURL, e.g. http://localhost:8080
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Do sends a request to the route registered at method and path (e.g. /users/:id) and decodes the response.
+// The request is a struct with `lite` tags, or nil when the route has no parameter.
+// A response with an error status is returned as an errors.HTTPError.
+func Do[Response any](ctx context.Context, c *Client, method, path string, request any) (Response, error) {
+	var response Response
+
+	req, err := c.newRequest(ctx, method, path, request)
+	if err != nil {
+		return response, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return response, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return response, decodeError(resp, body)
+	}
+
+	err = decodeResponse(resp.Header.Get("Content-Type"), body, &response)
+
+	return response, err
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, request any) (*http.Request, error) {
+	encoded, err := encodeRequest(path, request)
+	if err != nil {
+		return nil, err
+	}
+
+	target := c.baseURL + encoded.path
+	if len(encoded.query) > 0 {
+		target += "?" + encoded.query.Encode()
+	}
+
+	var body io.Reader
+	if encoded.body != nil {
+		body = bytes.NewReader(encoded.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+
+	for key, values := range encoded.header {
+		req.Header[key] = values
+	}
+
+	for _, cookie := range encoded.cookies {
+		req.AddCookie(cookie)
+	}
+
+	if encoded.contentType != "" {
+		req.Header.Set("Content-Type", encoded.contentType)
+	}
+
+	return req, nil
+}
+
+func decodeResponse(contentType string, body []byte, dst any) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	dstVal := reflect.ValueOf(dst).Elem()
+
+	switch {
+	case dstVal.Kind() == reflect.String:
+		dstVal.SetString(string(body))
+
+		return nil
+	case dstVal.Kind() == reflect.Slice && dstVal.Type().Elem().Kind() == reflect.Uint8:
+		dstVal.SetBytes(body)
+
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return xml.Unmarshal(body, dst)
+	default:
+		return json.Unmarshal(body, dst)
+	}
+}
+
+// decodeError decodes an error response, written either as an HTTPError or as problem details
+func decodeError(resp *http.Response, body []byte) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var (
+		httpError errors.HTTPError
+		problem   errors.ProblemDetails
+		err       error
+	)
+
+	switch mediaType {
+	case errors.ProblemJSONContentType:
+		if err = json.Unmarshal(body, &problem); err == nil {
+			httpError = problem.HTTPError()
+		}
+	case errors.ProblemXMLContentType:
+		if err = xml.Unmarshal(body, &problem); err == nil {
+			httpError = problem.HTTPError()
+		}
+	case "application/xml", "text/xml":
+		err = xml.Unmarshal(body, &httpError)
+	case "application/json":
+		err = json.Unmarshal(body, &httpError)
+	default:
+		err = fmt.Errorf("unexpected content type %q", mediaType)
+	}
+
+	if err != nil || httpError.Status == 0 {
+		message := strings.TrimSpace(string(body))
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+
+		return errors.NewError(resp.StatusCode, message)
+	}
+
+	return httpError
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	goErrors "errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/go-lite/lite"
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID    uint64 `json:"id"    xml:"id"`
+	Name  string `json:"name"  xml:"name"`
+	Email string `json:"email" xml:"email"`
+}
+
+type userBody struct {
+	Name  string `json:"name"  validate:"required"`
+	Email string `json:"email"`
+}
+
+type createUserReq struct {
+	ID     uint64   `lite:"path=id"`
+	Tenant string   `lite:"header=X-Tenant"`
+	Body   userBody `lite:"req=body"`
+}
+
+type getUserReq struct {
+	ID     uint64 `lite:"path=id"`
+	Fields string `lite:"query=fields"`
+}
+
+type formBody struct {
+	Name string `form:"name"`
+}
+
+type formReq struct {
+	Body formBody `lite:"req=body,application/x-www-form-urlencoded"`
+}
+
+func newTestServer(t *testing.T) *Client {
+	app := lite.New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	lite.Post(app, "/users/:id", func(c *lite.ContextWithRequest[createUserReq]) (user, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return user{}, err
+		}
+
+		return user{ID: req.ID, Name: req.Body.Name, Email: req.Tenant}, nil
+	})
+
+	lite.Get(app, "/users/:id", func(c *lite.ContextWithRequest[getUserReq]) (user, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return user{}, err
+		}
+
+		if req.ID == 0 {
+			return user{}, errors.NewNotFoundError("user not found")
+		}
+
+		return user{ID: req.ID, Name: req.Fields}, nil
+	})
+
+	lite.Post(app, "/forms", func(c *lite.ContextWithRequest[formReq]) (string, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return "", err
+		}
+
+		return req.Body.Name, nil
+	})
+
+	lite.Get(app, "/ping", func(_ *lite.ContextNoRequest) (string, error) {
+		return "pong", nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	t.Cleanup(func() {
+		_ = app.Shutdown()
+	})
+
+	return New("http://"+ln.Addr().String()+"/", WithHeader("X-Tenant", "default"))
+}
+
+func TestDo(t *testing.T) {
+	c := newTestServer(t)
+
+	created, err := Do[user](context.Background(), c, http.MethodPost, "/users/:id", createUserReq{
+		ID:     1,
+		Tenant: "acme",
+		Body:   userBody{Name: "john"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 1, Name: "john", Email: "acme"}, created)
+
+	found, err := Do[user](context.Background(), c, http.MethodGet, "/users/:id", &getUserReq{ID: 2, Fields: "name"})
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 2, Name: "name"}, found)
+
+	name, err := Do[string](context.Background(), c, http.MethodPost, "/forms", formReq{Body: formBody{Name: "john"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "john", name)
+
+	pong, err := Do[string](context.Background(), c, http.MethodGet, "/ping", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", pong)
+}
+
+func TestDo_DefaultHeader(t *testing.T) {
+	c := newTestServer(t)
+
+	created, err := Do[user](context.Background(), c, http.MethodPost, "/users/:id", createUserReq{
+		ID:   1,
+		Body: userBody{Name: "john"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", created.Email)
+}
+
+func TestDo_Error(t *testing.T) {
+	c := newTestServer(t)
+
+	_, err := Do[user](context.Background(), c, http.MethodGet, "/users/:name", getUserReq{})
+	assert.EqualError(t, err, "missing path parameter: name")
+
+	_, err = Do[user](context.Background(), c, http.MethodGet, "/users/:id", getUserReq{})
+
+	var httpError errors.HTTPError
+
+	assert.True(t, goErrors.As(err, &httpError))
+	assert.Equal(t, http.StatusNotFound, httpError.Status)
+	assert.Equal(t, "user not found", httpError.Message)
+
+	_, err = Do[user](context.Background(), c, http.MethodPost, "/users/:id", createUserReq{ID: 1})
+
+	assert.True(t, goErrors.As(err, &httpError))
+	assert.Equal(t, http.StatusUnprocessableEntity, httpError.Status)
+	assert.Equal(t, []errors.FieldError{{Field: "name", In: "body", Message: "is required"}}, httpError.Errors)
+
+	_, err = Do[user](context.Background(), c, http.MethodGet, "/unknown", nil)
+
+	assert.True(t, goErrors.As(err, &httpError))
+	assert.Equal(t, http.StatusNotFound, httpError.Status)
+	assert.Equal(t, "Cannot GET /unknown", httpError.Message)
+}
+
+func TestDecodeError_ProblemDetails(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusConflict, Header: http.Header{}}
+	resp.Header.Set("Content-Type", errors.ProblemXMLContentType)
+
+	body := `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Conflict</title><status>409</status>` +
+		`<detail>already exists</detail><id>42</id></problem>`
+
+	err := decodeError(resp, []byte(body))
+	assert.Equal(t, errors.HTTPError{ID: "42", Status: http.StatusConflict, Message: "already exists"}, err)
+
+	resp.Header.Set("Content-Type", errors.ProblemJSONContentType)
+
+	err = decodeError(resp, []byte(`{"type":"about:blank","title":"Conflict","status":409,"id":"42"}`))
+	assert.Equal(t, errors.HTTPError{ID: "42", Status: http.StatusConflict, Message: "Conflict"}, err)
+}
+
+func TestEncodeRequest(t *testing.T) {
+	type embedded struct {
+		Token string `lite:"cookie=token"`
+	}
+
+	type request struct {
+		embedded
+		Org   string   `lite:"path=org"`
+		Tags  []string `lite:"query=tags"`
+		Limit *int     `lite:"query=limit"`
+		Page  int      `lite:"query=page"`
+		IDs   []int    `lite:"query=ids,style=pipeDelimited"`
+		Langs []string `lite:"header=Accept-Lang"`
+		Body  []byte   `lite:"req=body,application/octet-stream"`
+	}
+
+	encoded, err := encodeRequest("/orgs/:org/repos", request{
+		embedded: embedded{Token: "secret"},
+		Org:      "go lite",
+		Tags:     []string{"a", "b"},
+		Page:     2,
+		IDs:      []int{1, 2},
+		Langs:    []string{"en", "fr"},
+		Body:     []byte("data"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/orgs/go%20lite/repos", encoded.path)
+	assert.Equal(t, "ids=1%7C2&page=2&tags=a&tags=b", encoded.query.Encode())
+	assert.Equal(t, "en,fr", encoded.header.Get("Accept-Lang"))
+	assert.Equal(t, "token", encoded.cookies[0].Name)
+	assert.Equal(t, []byte("data"), encoded.body)
+	assert.Equal(t, "application/octet-stream", encoded.contentType)
+
+	_, err = encodeRequest("/", struct{ Name string }{})
+	assert.EqualError(t, err, "missing tag for field Name")
+
+	_, err = encodeRequest("/", "request")
+	assert.EqualError(t, err, "request must be a struct, got string")
+}
@@ -0,0 +1,359 @@
+package client
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type encodedRequest struct {
+	path        string
+	query       url.Values
+	header      http.Header
+	cookies     []*http.Cookie
+	body        []byte
+	contentType string
+}
+
+var pathParamRegexp = regexp.MustCompile(`:([a-zA-Z0-9_]+)`)
+
+// encodeRequest spreads the fields of a request struct over the path, query, headers, cookies and body,
+// as the server reads them.
+func encodeRequest(path string, request any) (encodedRequest, error) {
+	encoded := encodedRequest{
+		path:   path,
+		query:  url.Values{},
+		header: http.Header{},
+	}
+
+	if request != nil {
+		val := reflect.ValueOf(request)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		if val.Kind() != reflect.Struct {
+			return encoded, fmt.Errorf("request must be a struct, got %s", val.Kind())
+		}
+
+		if err := encoded.encodeFields(val); err != nil {
+			return encoded, err
+		}
+	}
+
+	if param := pathParamRegexp.FindStringSubmatch(encoded.path); param != nil {
+		return encoded, fmt.Errorf("missing path parameter: %s", param[1])
+	}
+
+	return encoded, nil
+}
+
+func (e *encodedRequest) encodeFields(val reflect.Value) error {
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		fieldVal := val.Field(i)
+		tag := field.Tag.Get("lite")
+
+		if fieldVal.Kind() == reflect.Struct && tag == "" {
+			if err := e.encodeFields(fieldVal); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if tag == "" {
+			return fmt.Errorf("missing tag for field %s", field.Name)
+		}
+
+		tagMap := parseTag(tag)
+
+		if tagMap["req"] == "body" {
+			if err := e.encodeBody(bodyContentType(tagMap), fieldVal); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		values, err := formatValues(fieldVal)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		switch {
+		case tagMap["path"] != "":
+			e.path = replacePathParam(e.path, tagMap["path"], url.PathEscape(strings.Join(values, ",")))
+		case tagMap["query"] != "":
+			e.query[tagMap["query"]] = append(e.query[tagMap["query"]], joinValues(values, tagMap)...)
+		case tagMap["header"] != "":
+			e.header.Set(tagMap["header"], strings.Join(values, ","))
+		case tagMap["cookie"] != "":
+			for _, value := range joinValues(values, tagMap) {
+				e.cookies = append(e.cookies, &http.Cookie{Name: tagMap["cookie"], Value: value})
+			}
+		}
+	}
+
+	return nil
+}
+
+// joinValues joins the values of an array query or cookie parameter,
+// as declared by the style and explode options of its tag. They are repeated by default.
+func joinValues(values []string, tagMap map[string]string) []string {
+	style := tagMap["style"]
+	explode := style == "" || style == "form"
+
+	if value, ok := tagMap["explode"]; ok {
+		explode, _ = strconv.ParseBool(value)
+	}
+
+	if explode || len(values) < 2 {
+		return values
+	}
+
+	switch style {
+	case "spaceDelimited":
+		return []string{strings.Join(values, " ")}
+	case "pipeDelimited":
+		return []string{strings.Join(values, "|")}
+	default:
+		return []string{strings.Join(values, ",")}
+	}
+}
+
+func replacePathParam(path, name, value string) string {
+	return pathParamRegexp.ReplaceAllStringFunc(path, func(param string) string {
+		if param[1:] == name {
+			return value
+		}
+
+		return param
+	})
+}
+
+func (e *encodedRequest) encodeBody(contentType string, val reflect.Value) error {
+	var err error
+
+	e.contentType = contentType
+
+	switch {
+	case contentType == "application/json":
+		e.body, err = json.Marshal(val.Interface())
+	case contentType == "application/xml", contentType == "text/xml":
+		e.body, err = xml.Marshal(val.Interface())
+	case contentType == "application/x-www-form-urlencoded":
+		var form url.Values
+
+		form, err = formValues(val)
+		e.body = []byte(form.Encode())
+	case contentType == "multipart/form-data":
+		err = e.encodeMultipart(val)
+	case strings.HasPrefix(contentType, "text/"):
+		e.body = []byte(reflect.Indirect(val).String())
+	default:
+		data, ok := reflect.Indirect(val).Interface().([]byte)
+		if !ok {
+			return fmt.Errorf("expected []byte for %s body, got %s", contentType, val.Type())
+		}
+
+		e.body = data
+	}
+
+	return err
+}
+
+// formValues returns the values of the fields of a form body, named by the form tag as on the server side
+func formValues(val reflect.Value) (url.Values, error) {
+	form := url.Values{}
+
+	val = reflect.Indirect(val)
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct for form body, got %s", val.Kind())
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if !field.IsExported() || field.Type == reflect.TypeOf(&multipart.FileHeader{}) {
+			continue
+		}
+
+		values, err := formatValues(val.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		if len(values) > 0 {
+			form[formName(field)] = values
+		}
+	}
+
+	return form, nil
+}
+
+func (e *encodedRequest) encodeMultipart(val reflect.Value) error {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	form, err := formValues(val)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range form {
+		for _, value := range values {
+			if err = writer.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	val = reflect.Indirect(val)
+
+	for i := 0; i < val.NumField(); i++ {
+		fileHeader, ok := val.Field(i).Interface().(*multipart.FileHeader)
+		if !ok || fileHeader == nil {
+			continue
+		}
+
+		if err = writeFile(writer, formName(val.Type().Field(i)), fileHeader); err != nil {
+			return err
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	e.body = body.Bytes()
+	e.contentType = writer.FormDataContentType()
+
+	return nil
+}
+
+func writeFile(writer *multipart.Writer, name string, fileHeader *multipart.FileHeader) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	part, err := writer.CreateFormFile(name, fileHeader.Filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file)
+
+	return err
+}
+
+func formName(field reflect.StructField) string {
+	if name := field.Tag.Get("form"); name != "" {
+		return name
+	}
+
+	return field.Name
+}
+
+// formatValues formats a parameter value as strings, one per element of a slice.
+// Nil pointers and empty strings are not sent.
+func formatValues(val reflect.Value) ([]string, error) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, nil
+		}
+
+		val = val.Elem()
+	}
+
+	if (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && val.Type().Elem().Kind() != reflect.Uint8 {
+		var values []string
+
+		for i := 0; i < val.Len(); i++ {
+			elemValues, err := formatValues(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, elemValues...)
+		}
+
+		return values, nil
+	}
+
+	value, err := formatValue(val)
+	if err != nil || value == "" {
+		return nil, err
+	}
+
+	return []string{value}, nil
+}
+
+func formatValue(val reflect.Value) (string, error) {
+	if marshaler, ok := val.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+
+		return string(text), err
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		return string(val.Bytes()), nil
+	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Array, reflect.Chan,
+		reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Struct, reflect.UnsafePointer:
+		fallthrough
+	default:
+		return "", fmt.Errorf("unsupported parameter type %s", val.Type())
+	}
+}
+
+func parseTag(tag string) map[string]string {
+	tagMap := make(map[string]string)
+
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		tagMap[key] = value
+	}
+
+	return tagMap
+}
+
+// bodyContentType returns the content type declared on a body field: `lite:"req=body,application/xml"`
+func bodyContentType(tagMap map[string]string) string {
+	for key := range tagMap {
+		if key != "req" {
+			return key
+		}
+	}
+
+	return "application/json"
+}
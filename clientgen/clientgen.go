@@ -0,0 +1,365 @@
+// Package clientgen generates a typed Go client of the routes registered on a lite App.
+//
+// Each route becomes a method of the generated Client, named after its operation ID.
+// The methods take the request struct of the route and return its response type,
+// both imported from the server packages, and call the route with the client package.
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-lite/lite"
+)
+
+const clientImportPath = "github.com/go-lite/lite/client"
+
+// Options configures the generated client
+type Options struct {
+	// Package is the name of the generated package, "client" by default
+	Package string
+}
+
+// Generate returns the source of a Go package calling the routes registered on the App.
+// The request and response types must be declared in importable packages, not in package main.
+func Generate(app *lite.App, options Options) ([]byte, error) {
+	if options.Package == "" {
+		options.Package = "client"
+	}
+
+	g := &generator{
+		imports: map[string]string{"context": "context", clientImportPath: "client"},
+		names:   map[string]bool{"context": true, "client": true},
+	}
+
+	var methods bytes.Buffer
+
+	methodNames := map[string]bool{"New": true}
+
+	for _, route := range app.Routes() {
+		// event streams, WebSocket connections and streamed bodies are not request/response calls
+		if route.ContentType == "text/event-stream" || route.StatusCode == http.StatusSwitchingProtocols ||
+			route.Streamed {
+			continue
+		}
+
+		if err := g.writeMethod(&methods, route, methodNames); err != nil {
+			return nil, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+		}
+	}
+
+	var src bytes.Buffer
+
+	fmt.Fprintf(&src, "// Code generated by lite clientgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", options.Package)
+	g.writeImports(&src)
+	fmt.Fprintf(&src, "// Client is a typed client of the %s API\n", apiTitle(app))
+	fmt.Fprintf(&src, "type Client struct {\n*client.Client\n}\n\n")
+	fmt.Fprintf(&src, "// New returns a Client of the server at baseURL\n")
+	fmt.Fprintf(&src, "func New(baseURL string, options ...client.Option) *Client {\n")
+	fmt.Fprintf(&src, "return &Client{Client: client.New(baseURL, options...)}\n}\n")
+	src.Write(methods.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+func apiTitle(app *lite.App) string {
+	if app.OpenAPISpec.Info != nil && app.OpenAPISpec.Info.Title != "" {
+		return strconv.Quote(app.OpenAPISpec.Info.Title)
+	}
+
+	return "lite"
+}
+
+type generator struct {
+	imports map[string]string // import path -> package name
+	names   map[string]bool   // package names in use
+}
+
+func (g *generator) writeMethod(w *bytes.Buffer, route lite.RouteInfo, methodNames map[string]bool) error {
+	name := uniqueName(methodName(route), methodNames)
+
+	responseType, err := g.typeName(route.ResponseType)
+	if err != nil {
+		return err
+	}
+
+	hasRequest := route.RequestType.Kind() != reflect.Interface
+
+	requestParam, requestArg := "", "nil"
+
+	if hasRequest {
+		requestType, err := g.typeName(route.RequestType)
+		if err != nil {
+			return err
+		}
+
+		requestParam, requestArg = ", request "+requestType, "request"
+	}
+
+	fmt.Fprintf(w, "\n// %s calls %s %s\n", name, route.Method, route.Path)
+	fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context%s) (%s, error) {\n", name, requestParam, responseType)
+	fmt.Fprintf(w, "return client.Do[%s](ctx, c.Client, %q, %q, %s)\n}\n", responseType, route.Method, route.Path, requestArg)
+
+	return nil
+}
+
+func (g *generator) writeImports(w *bytes.Buffer) {
+	paths := make([]string, 0, len(g.imports))
+	for importPath := range g.imports {
+		paths = append(paths, importPath)
+	}
+
+	// standard library first, as goimports does
+	sort.Slice(paths, func(i, j int) bool {
+		if isStandard(paths[i]) != isStandard(paths[j]) {
+			return isStandard(paths[i])
+		}
+
+		return paths[i] < paths[j]
+	})
+
+	fmt.Fprintf(w, "import (\n")
+
+	for i, importPath := range paths {
+		if i > 0 && isStandard(paths[i-1]) && !isStandard(importPath) {
+			fmt.Fprintf(w, "\n")
+		}
+
+		if name := g.imports[importPath]; name != path.Base(importPath) {
+			fmt.Fprintf(w, "%s %q\n", name, importPath)
+		} else {
+			fmt.Fprintf(w, "%q\n", importPath)
+		}
+	}
+
+	fmt.Fprintf(w, ")\n\n")
+}
+
+// typeName returns the Go expression of a type, importing the packages it is declared in
+func (g *generator) typeName(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+
+		if strings.Contains(t.Name(), "[") {
+			return "", fmt.Errorf("generic type %s is not supported", t)
+		}
+
+		if !token.IsExported(t.Name()) {
+			return "", fmt.Errorf("type %s is not exported", t)
+		}
+
+		if t.PkgPath() == "main" {
+			return "", fmt.Errorf("type %s is declared in package main, move it to an importable package", t)
+		}
+
+		return g.importName(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeName(t.Elem())
+
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeName(t.Elem())
+
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeName(t.Elem())
+
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeName(t.Key())
+		if err != nil {
+			return "", err
+		}
+
+		elem, err := g.typeName(t.Elem())
+
+		return fmt.Sprintf("map[%s]%s", key, elem), err
+	case reflect.Struct:
+		return g.structName(t)
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any", nil
+		}
+
+		return "", fmt.Errorf("unnamed interface type %s is not supported", t)
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.String,
+		reflect.UnsafePointer:
+		fallthrough
+	default:
+		return "", fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+func (g *generator) structName(t reflect.Type) (string, error) {
+	var b strings.Builder
+
+	b.WriteString("struct {\n")
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		fieldType, err := g.typeName(field.Type)
+		if err != nil {
+			return "", err
+		}
+
+		if !field.Anonymous {
+			b.WriteString(field.Name + " ")
+		}
+
+		b.WriteString(fieldType)
+
+		if field.Tag != "" {
+			b.WriteString(" " + strconv.Quote(string(field.Tag)))
+		}
+
+		b.WriteString("\n")
+	}
+
+	b.WriteString("}")
+
+	return b.String(), nil
+}
+
+// importName imports a package and returns its name, renamed when two packages have the same name
+func (g *generator) importName(importPath string) string {
+	if name, ok := g.imports[importPath]; ok {
+		return name
+	}
+
+	base := packageName(importPath)
+	name := base
+
+	for i := 2; g.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	g.imports[importPath] = name
+	g.names[name] = true
+
+	return name
+}
+
+// packageName guesses the name of a package from its import path, e.g. "v2" for ".../fiber/v2" is "fiber"
+func packageName(importPath string) string {
+	base := path.Base(importPath)
+
+	if len(base) > 1 && base[0] == 'v' && isDigits(base[1:]) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+
+		return -1
+	}, base)
+
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "pkg" + name
+	}
+
+	return name
+}
+
+func isStandard(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+
+	return !strings.Contains(first, ".")
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// methodName converts the operation ID of a route to an exported Go identifier.
+// The default operation IDs, e.g. GET/users/:id, become GetUsersByID.
+func methodName(route lite.RouteInfo) string {
+	operationID := route.OperationID
+	if operationID == "" {
+		operationID = route.Method + route.Path
+	}
+
+	var b strings.Builder
+
+	for _, word := range strings.FieldsFunc(operationID, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':'
+	}) {
+		if strings.HasPrefix(word, ":") {
+			b.WriteString("By")
+
+			word = word[1:]
+		}
+
+		for _, part := range strings.Split(word, ":") {
+			b.WriteString(exportWord(part))
+		}
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Call" + name
+	}
+
+	return name
+}
+
+func exportWord(word string) string {
+	if word == "" {
+		return ""
+	}
+
+	if initialism := strings.ToUpper(word); commonInitialisms[initialism] {
+		return initialism
+	}
+
+	if strings.ToUpper(word) == word {
+		word = strings.ToLower(word)
+	}
+
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "JSON": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+func uniqueName(name string, names map[string]bool) string {
+	unique := name
+
+	for i := 2; names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+
+	names[unique] = true
+
+	return unique
+}
@@ -0,0 +1,158 @@
+package clientgen
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-lite/lite"
+	"github.com/stretchr/testify/assert"
+)
+
+type GetUserReq struct {
+	ID uint64 `lite:"path=id"`
+}
+
+type User struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type unexported struct{}
+
+func TestGenerate(t *testing.T) {
+	app := lite.New()
+
+	lite.Get(app, "/users/:id", func(_ *lite.ContextWithRequest[GetUserReq]) (User, error) {
+		return User{}, nil
+	})
+
+	lite.Get(app, "/users", func(_ *lite.ContextNoRequest) ([]User, error) {
+		return nil, nil
+	}).OperationID("listUsers")
+
+	lite.Post(app, "/users/:id/stats", func(_ *lite.ContextWithRequest[GetUserReq]) (map[string]struct {
+		Count int `json:"count"`
+	}, error,
+	) {
+		return nil, nil
+	})
+
+	lite.SSE(app, "/users/:id/events", func(_ *lite.EventStream[User], _ GetUserReq) error {
+		return nil
+	})
+
+	lite.Get(app, "/users/export", func(_ *lite.ContextNoRequest) (lite.NDJSON[User], error) {
+		return nil, nil
+	})
+
+	lite.WebSocket(app, "/users/:id/chat", func(_ *lite.WebSocketConn[User, User], _ GetUserReq) error {
+		return nil
+	})
+
+	src, err := Generate(app, Options{Package: "users"})
+	assert.NoError(t, err)
+
+	expected := `// Code generated by lite clientgen. DO NOT EDIT.
+
+package users
+
+import (
+	"context"
+
+	"github.com/go-lite/lite/client"
+	"github.com/go-lite/lite/clientgen"
+)
+
+// Client is a typed client of the "OpenAPI" API
+type Client struct {
+	*client.Client
+}
+
+// New returns a Client of the server at baseURL
+func New(baseURL string, options ...client.Option) *Client {
+	return &Client{Client: client.New(baseURL, options...)}
+}
+
+// GetUsersByID calls GET /users/:id
+func (c *Client) GetUsersByID(ctx context.Context, request clientgen.GetUserReq) (clientgen.User, error) {
+	return client.Do[clientgen.User](ctx, c.Client, "GET", "/users/:id", request)
+}
+
+// ListUsers calls GET /users
+func (c *Client) ListUsers(ctx context.Context) ([]clientgen.User, error) {
+	return client.Do[[]clientgen.User](ctx, c.Client, "GET", "/users", nil)
+}
+
+// PostUsersByIDStats calls POST /users/:id/stats
+func (c *Client) PostUsersByIDStats(ctx context.Context, request clientgen.GetUserReq) (map[string]struct {
+	Count int "json:\"count\""
+}, error) {
+	return client.Do[map[string]struct {
+		Count int "json:\"count\""
+	}](ctx, c.Client, "POST", "/users/:id/stats", request)
+}
+`
+
+	assert.Equal(t, expected, string(src))
+}
+
+func TestGenerate_Error(t *testing.T) {
+	app := lite.New()
+
+	lite.Get(app, "/unexported", func(_ *lite.ContextNoRequest) (unexported, error) {
+		return unexported{}, nil
+	})
+
+	_, err := Generate(app, Options{})
+	assert.EqualError(t, err, "route GET /unexported: type clientgen.unexported is not exported")
+
+	app = lite.New()
+
+	lite.Get(app, "/generic", func(_ *lite.ContextNoRequest) (Page[User], error) {
+		return Page[User]{}, nil
+	})
+
+	_, err = Generate(app, Options{})
+	assert.ErrorContains(t, err, "is not supported")
+}
+
+func TestGenerator_TypeName(t *testing.T) {
+	g := &generator{imports: map[string]string{}, names: map[string]bool{"clientgen": true}}
+
+	name, err := g.typeName(reflect.TypeOf(map[string][]*User{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "map[string][]*clientgen2.User", name)
+
+	name, err = g.typeName(reflect.TypeOf([2]time.Duration{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "[2]time.Duration", name)
+
+	_, err = g.typeName(reflect.TypeOf(make(chan int)))
+	assert.EqualError(t, err, "type chan int is not supported")
+}
+
+func TestMethodName(t *testing.T) {
+	tests := map[string]lite.RouteInfo{
+		"GetUsersByID":          {Method: "GET", Path: "/users/:id", OperationID: "GET/users/:id"},
+		"DeleteOrgsByOrgMember": {Method: "DELETE", Path: "/orgs/:org/member", OperationID: "DELETE/orgs/:org/member"},
+		"CreateExample":         {Method: "POST", Path: "/example/:id", OperationID: "createExample"},
+		"GetAPIJSON":            {Method: "GET", Path: "/api/json"},
+		"Call2fa":               {Method: "GET", Path: "/2fa", OperationID: "2fa"},
+	}
+
+	for expected, route := range tests {
+		assert.Equal(t, expected, methodName(route))
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "fiber", packageName("github.com/gofiber/fiber/v2"))
+	assert.Equal(t, "golite", packageName("github.com/go-lite/go-lite"))
+	assert.Equal(t, "time", packageName("time"))
+	assert.Equal(t, "pkg3d", packageName("example.com/3d"))
+}
@@ -0,0 +1,112 @@
+// Command lite-client generates a typed Go client of a lite App.
+//
+// The routes are registered by a function of an importable package returning the App, without starting it:
+//
+//	func NewApp() *lite.App
+//
+// lite-client runs that function in a temporary program of the current module and writes the client source:
+//
+//	go run github.com/go-lite/lite/cmd/lite-client -pkg example.com/api/server -func NewApp -out client/client.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+)
+
+var generatorTemplate = template.Must(template.New("main").Parse(`// Code generated by lite-client. DO NOT EDIT.
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/go-lite/lite/clientgen"
+
+	server {{ printf "%q" .Pkg }}
+)
+
+func main() {
+	src, err := clientgen.Generate(server.{{ .Func }}(), clientgen.Options{Package: {{ printf "%q" .Package }}})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = os.WriteFile({{ printf "%q" .Out }}, src, 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+`))
+
+type config struct {
+	Pkg     string
+	Func    string
+	Out     string
+	Package string
+}
+
+func main() {
+	var cfg config
+
+	flag.StringVar(&cfg.Pkg, "pkg", "", "import path of the package registering the routes")
+	flag.StringVar(&cfg.Func, "func", "NewApp", "function of the package returning the *lite.App")
+	flag.StringVar(&cfg.Out, "out", "client/client.go", "file the client is written to")
+	flag.StringVar(&cfg.Package, "package", "", "name of the generated package, the directory of -out by default")
+	flag.Parse()
+
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cfg config) error {
+	if cfg.Pkg == "" {
+		return fmt.Errorf("missing -pkg flag")
+	}
+
+	out, err := filepath.Abs(cfg.Out)
+	if err != nil {
+		return err
+	}
+
+	cfg.Out = out
+
+	if cfg.Package == "" {
+		cfg.Package = filepath.Base(filepath.Dir(out))
+	}
+
+	if err = os.MkdirAll(filepath.Dir(out), 0o750); err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+
+	if err = generatorTemplate.Execute(&src, cfg); err != nil {
+		return err
+	}
+
+	// The generator is run from the current module, so that it can import the server package
+	dir, err := os.MkdirTemp(".", "liteclient")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err = os.WriteFile(filepath.Join(dir, "main.go"), src.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", "./"+filepath.ToSlash(dir))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
@@ -0,0 +1,257 @@
+package lite
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Codec decodes the request bodies and encodes the response bodies of a media type.
+// The codecs of an App are registered with App.RegisterCodec, e.g. to support MessagePack or YAML bodies:
+//
+//	type yamlCodec struct{}
+//
+//	func (yamlCodec) MediaType() string { return "application/yaml" }
+//	func (yamlCodec) StructTag() string { return "yaml" }
+//
+//	func (yamlCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+//		return yaml.Unmarshal(ctx.Request.Body(), dst)
+//	}
+//
+//	func (yamlCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+//		body, err := yaml.Marshal(src)
+//		ctx.SetBody(body)
+//		return err
+//	}
+type Codec interface {
+	// MediaType returns the media type handled by the codec, e.g. application/json.
+	// A media type range such as image/* handles the media types without their own codec.
+	MediaType() string
+
+	// Decode decodes the body of the request into dst, a pointer to the body field of the request
+	Decode(ctx *fasthttp.RequestCtx, dst any) error
+
+	// Encode writes src as the body of the response
+	Encode(ctx *fasthttp.RequestCtx, src any) error
+
+	// StructTag returns the struct tag naming the fields of a body in this media type,
+	// used to document their names in the OpenAPI spec, e.g. json
+	StructTag() string
+}
+
+// codecRegistry holds the Codecs of an App by media type
+type codecRegistry map[string]Codec
+
+// defaultCodecs returns the codecs of the media types supported out of the box
+func defaultCodecs() codecRegistry {
+	codecs := codecRegistry{}
+
+	codecs.register(
+		jsonCodec{},
+		xmlCodec{mediaType: "application/xml"},
+		xmlCodec{mediaType: "text/xml"},
+		formCodec{mediaType: "application/x-www-form-urlencoded"},
+		formCodec{mediaType: "multipart/form-data"},
+		textCodec{mediaType: "text/plain"},
+		textCodec{mediaType: "text/html"},
+		binaryCodec{mediaType: "application/octet-stream", structTag: "binary"},
+		binaryCodec{mediaType: "application/pdf", structTag: "pdf"},
+		binaryCodec{mediaType: "application/zip", structTag: "binary"},
+		binaryCodec{mediaType: "image/png", structTag: "png"},
+		binaryCodec{mediaType: "image/jpeg", structTag: "jpeg"},
+		binaryCodec{mediaType: "image/*", structTag: "binary"},
+	)
+
+	return codecs
+}
+
+func (r codecRegistry) register(codecs ...Codec) {
+	for _, codec := range codecs {
+		r[strings.ToLower(codec.MediaType())] = codec
+	}
+}
+
+// lookup returns the codec of a content type, ignoring its parameters (e.g. charset).
+// Media types without their own codec fall back to the codec of their structured syntax suffix (RFC 6839)
+// when they are application types, e.g. application/merge-patch+json to application/json,
+// then to the codec of their range, e.g. image/svg+xml to image/*.
+func (r codecRegistry) lookup(contentType string) (Codec, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	if codec, ok := r[mediaType]; ok {
+		return codec, true
+	}
+
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 && strings.HasPrefix(mediaType, "application/") {
+		if codec, ok := r["application/"+mediaType[i+1:]]; ok {
+			return codec, true
+		}
+	}
+
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		codec, ok := r[major+"/*"]
+
+		return codec, ok
+	}
+
+	return nil, false
+}
+
+// RegisterCodec registers codecs for the request and response bodies of their media type,
+// replacing the codec already registered for it, if any.
+// It must be called before registering routes, so that their bodies are documented accordingly.
+func (s *App) RegisterCodec(codecs ...Codec) *App {
+	s.codecs.register(codecs...)
+
+	return s
+}
+
+// Codec returns the codec registered for a content type
+func (s *App) Codec(contentType string) (Codec, bool) {
+	return s.codecs.lookup(contentType)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string {
+	return "application/json"
+}
+
+func (jsonCodec) StructTag() string {
+	return "json"
+}
+
+func (jsonCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	return json.Unmarshal(ctx.Request.Body(), dst)
+}
+
+func (jsonCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	return json.NewEncoder(ctx).Encode(src)
+}
+
+type xmlCodec struct {
+	mediaType string
+}
+
+func (c xmlCodec) MediaType() string {
+	return c.mediaType
+}
+
+func (xmlCodec) StructTag() string {
+	return "xml"
+}
+
+func (xmlCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	return xml.Unmarshal(ctx.Request.Body(), dst)
+}
+
+func (xmlCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	return xml.NewEncoder(ctx).Encode(src)
+}
+
+type formCodec struct {
+	mediaType string
+}
+
+func (c formCodec) MediaType() string {
+	return c.mediaType
+}
+
+func (formCodec) StructTag() string {
+	return "form"
+}
+
+func (c formCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	if c.mediaType == "multipart/form-data" {
+		return parseMultipartForm(ctx, dst)
+	}
+
+	return parseFormURLEncoded(ctx, dst)
+}
+
+func (formCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	form, ok := src.(map[string]string)
+	if !ok {
+		return fmt.Errorf("expected map[string]string for form data serialization")
+	}
+
+	formData := url.Values{}
+
+	for key, value := range form {
+		formData.Set(key, value)
+	}
+
+	ctx.SetBody([]byte(formData.Encode()))
+
+	return nil
+}
+
+type textCodec struct {
+	mediaType string
+}
+
+func (c textCodec) MediaType() string {
+	return c.mediaType
+}
+
+func (textCodec) StructTag() string {
+	return "txt"
+}
+
+func (textCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	dstVal := reflect.ValueOf(dst).Elem()
+	if dstVal.Kind() != reflect.String {
+		return fmt.Errorf("expected string for text deserialization")
+	}
+
+	dstVal.SetString(string(ctx.Request.Body()))
+
+	return nil
+}
+
+func (textCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() != reflect.String {
+		return fmt.Errorf("expected string for text serialization")
+	}
+
+	ctx.SetBodyString(srcVal.String())
+
+	return nil
+}
+
+type binaryCodec struct {
+	mediaType string
+	structTag string
+}
+
+func (c binaryCodec) MediaType() string {
+	return c.mediaType
+}
+
+func (c binaryCodec) StructTag() string {
+	return c.structTag
+}
+
+func (binaryCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	return parseBinaryData(ctx, dst)
+}
+
+func (c binaryCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	data, ok := src.([]byte)
+	if !ok && c.mediaType == "application/octet-stream" {
+		return fmt.Errorf("expected []byte for octet-stream serialization")
+	} else if !ok {
+		return fmt.Errorf("expected []byte for binary file serialization")
+	}
+
+	ctx.SetBody(data)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package lite
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+// upperCodec is a JSON codec whose responses are upper-cased, to tell it apart from the default one
+type upperCodec struct{}
+
+func (upperCodec) MediaType() string {
+	return "application/vnd.upper+json"
+}
+
+func (upperCodec) StructTag() string {
+	return "upper"
+}
+
+func (upperCodec) Decode(ctx *fasthttp.RequestCtx, dst any) error {
+	return json.Unmarshal(ctx.Request.Body(), dst)
+}
+
+func (upperCodec) Encode(ctx *fasthttp.RequestCtx, src any) error {
+	body, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetBodyString(strings.ToUpper(string(body)))
+
+	return nil
+}
+
+type upperBody struct {
+	Name string `json:"name" upper:"NAME"`
+}
+
+type upperRequest struct {
+	Body upperBody `lite:"req=body,application/vnd.upper+json"`
+}
+
+func TestRegisterCodec(t *testing.T) {
+	app := New().RegisterCodec(upperCodec{})
+
+	Post(app, "/foo", func(c *ContextWithRequest[upperRequest]) (upperBody, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return upperBody{}, err
+		}
+
+		return req.Body, nil
+	}).SetResponseContentType("application/vnd.upper+json")
+
+	req := httptest.NewRequest("POST", "/foo", strings.NewReader(`{"name":"john"}`))
+	req.Header.Set("Content-Type", "application/vnd.upper+json; charset=utf-8")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 201, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"NAME":"JOHN"}`, string(body))
+
+	schema := app.OpenAPISpec.Components.Schemas["upperBody"].Value
+	assert.Contains(t, schema.Properties, "NAME")
+	assert.NotContains(t, schema.Properties, "name")
+}
+
+func TestCodecLookup(t *testing.T) {
+	app := New()
+
+	codec, ok := app.Codec("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, "json", codec.StructTag())
+
+	codec, ok = app.Codec("image/gif")
+	assert.True(t, ok)
+	assert.Equal(t, "image/*", codec.MediaType())
+
+	codec, ok = app.Codec("image/svg+xml")
+	assert.True(t, ok)
+	assert.Equal(t, "image/*", codec.MediaType())
+
+	codec, ok = app.Codec("application/problem+xml")
+	assert.True(t, ok)
+	assert.Equal(t, "xml", codec.StructTag())
+
+	_, ok = app.Codec("application/msgpack")
+	assert.False(t, ok)
+	assert.Equal(t, "json", getStructTag(app.codecs, "application/msgpack"))
+}
+
+func TestApp_Serializer(t *testing.T) {
+	app := New()
+	app.Serializer = func(ctx *fasthttp.RequestCtx, response any) error {
+		ctx.SetBodyString("serialized")
+
+		return nil
+	}
+
+	Get(app, "/foo", func(c *ContextNoRequest) (responsePath, error) {
+		return responsePath{}, nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/foo", nil))
+	assert.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "serialized", string(body))
+}
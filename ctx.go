@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"mime/multipart"
+	"net/url"
 	"reflect"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/valyala/fasthttp"
@@ -24,6 +26,8 @@ type Context[Request any] interface {
 	BodyRaw() []byte
 	ClearCookie(key ...string)
 	RequestContext() *fasthttp.RequestCtx
+	Identity() (Identity, bool)
+	IfMatch(etag string) error
 	SetUserContext(ctx context.Context)
 	Cookie(cookie *fiber.Cookie)
 	Cookies(key string, defaultValue ...string) string
@@ -56,6 +60,12 @@ type ContextNoRequest struct {
 	ctx  *fiber.Ctx
 	app  *App
 	path string
+
+	// binder of the request of the route, nil when the context is not created by a route handler
+	binder *binder
+
+	// whether the requests of the route must have an If-Match header, see Route.RequireIfMatch
+	requireIfMatch bool
 }
 
 type ContextWithRequest[Request any] struct {
@@ -77,22 +87,32 @@ func (c *ContextWithRequest[Request]) Requests() (Request, error) {
 
 	reqContext := c.RequestContext()
 
-	params := extractParams(c.path, string(reqContext.Path()))
-
 	switch typeOfReq.Kind() {
 	case reflect.Struct:
-		err := deserializeRequests(reqContext, &req, params)
+		b, err := c.requestBinder(typeOfReq)
+		if err != nil {
+			return req, err
+		}
+
+		bound := boundParams{}
+
+		err = c.bind(b, reqContext, reflect.ValueOf(&req).Elem(), bound)
+		if err != nil {
+			return req, err
+		}
+
+		err = b.validate(reflect.ValueOf(&req).Elem(), bound)
 		if err != nil {
 			return req, err
 		}
 	case reflect.String:
-		err := deserializeBody(reqContext, reflect.ValueOf(&req).Elem())
+		err := deserializeBody(reqContext, c.app.codecs, reflect.ValueOf(&req).Elem())
 		if err != nil {
 			return req, err
 		}
 	case reflect.Array, reflect.Slice:
 		if typeOfReq.Elem().Kind() == reflect.Uint8 {
-			err := deserializeBody(reqContext, reflect.ValueOf(&req).Elem())
+			err := deserializeBody(reqContext, c.app.codecs, reflect.ValueOf(&req).Elem())
 			if err != nil {
 				return req, err
 			}
@@ -111,6 +131,39 @@ func (c *ContextWithRequest[Request]) Requests() (Request, error) {
 	return req, nil
 }
 
+// requestBinder returns the binder of the route. Contexts created outside of a route handler compile it on each call.
+func (c *ContextNoRequest) requestBinder(requestType reflect.Type) (*binder, error) {
+	if c.binder != nil {
+		return c.binder, nil
+	}
+
+	return newBinder(requestType, c.app.codecs)
+}
+
+// bind binds the request to dstVal with the binder b, reading the path parameters from the Fiber route params.
+// Contexts created outside of a route handler match their path on each call.
+func (c *ContextNoRequest) bind(b *binder, reqContext *fasthttp.RequestCtx, dstVal reflect.Value, bound boundParams) error {
+	if c.binder == nil {
+		params := extractParams(c.path, string(reqContext.Path()))
+
+		return b.bind(reqContext, c.app.codecs, dstVal, mapParams(params), bound)
+	}
+
+	unescape := !c.ctx.App().Config().UnescapePath
+
+	return b.bind(reqContext, c.app.codecs, dstVal, func(name string) (string, bool) {
+		value := c.ctx.Params(name)
+
+		if unescape && strings.Contains(value, "%") {
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				return unescaped, true
+			}
+		}
+
+		return value, true
+	}, bound)
+}
+
 func (c *ContextNoRequest) Accepts(offers ...string) string {
 	return c.ctx.Accepts(offers...)
 }
@@ -2,82 +2,38 @@ package lite
 
 import (
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
+	"net/http"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/valyala/fasthttp"
 )
 
-func deserializeRequests(ctx *fasthttp.RequestCtx, dst any, params map[string]string) error {
-	return deserialize(ctx, reflect.ValueOf(dst).Elem(), params)
-}
-
-func deserialize(ctx *fasthttp.RequestCtx, dstVal reflect.Value, params map[string]string) error {
-	dstType := dstVal.Type()
-
-	for i := 0; i < dstType.NumField(); i++ {
-		field := dstType.Field(i)
-		fieldVal := dstVal.Field(i)
-		tag := field.Tag.Get("lite")
-
-		if fieldVal.Kind() == reflect.Struct && tag == "" {
-			if err := deserialize(ctx, fieldVal, params); err != nil {
-				return err
-			}
-
-			continue
-		}
-
-		if tag == "" {
-			return fmt.Errorf("missing tag for field %s", field.Name)
-		}
-
-		tagMap := parseTag(tag)
-
-		if val, ok := tagMap["req"]; ok && val == "body" {
-			if err := deserializeBody(ctx, fieldVal); err != nil {
-				return err
-			}
-		}
-
-		var valueStr string
+// deserialize binds the request to dstVal, compiling the binder of its type
+func deserialize(ctx *fasthttp.RequestCtx, codecs codecRegistry, dstVal reflect.Value, params map[string]string) error {
+	b, err := newBinder(dstVal.Type(), codecs)
+	if err != nil {
+		return err
+	}
 
-		switch {
-		case tagMap["path"] != "":
-			if params != nil {
-				pathKey := tagMap["path"]
+	return b.bind(ctx, codecs, dstVal, mapParams(params), nil)
+}
 
-				paramsValue, ok := params[pathKey]
-				if !ok {
-					return fmt.Errorf("missing path parameter: %s", pathKey)
-				}
+var cookieType = reflect.TypeOf(http.Cookie{})
 
-				valueStr = paramsValue
-			}
-		case tagMap["query"] != "":
-			queryKey := tagMap["query"]
-			if value := ctx.QueryArgs().Peek(queryKey); len(value) > 0 {
-				valueStr = string(value)
-			}
-		case tagMap["header"] != "":
-			headerKey := tagMap["header"]
-			if value := ctx.Request.Header.Peek(headerKey); len(value) > 0 {
-				valueStr = string(value)
-			}
-		}
+// cookieValues returns the values of the cookies of the request with the given name
+func cookieValues(ctx *fasthttp.RequestCtx, name string) []string {
+	var values []string
 
-		if valueStr != "" {
-			if err := setFieldValue(fieldVal, valueStr); err != nil {
-				return err
-			}
+	ctx.Request.Header.VisitAllCookie(func(key, value []byte) {
+		if string(key) == name && len(value) > 0 {
+			values = append(values, string(value))
 		}
-	}
+	})
 
-	return nil
+	return values
 }
 
 func parseTag(tag string) map[string]string {
@@ -95,35 +51,16 @@ func parseTag(tag string) map[string]string {
 	return tagMap
 }
 
-func deserializeBody(ctx *fasthttp.RequestCtx, fieldVal reflect.Value) error {
+// deserializeBody decodes the body of the request with the codec of its content type
+func deserializeBody(ctx *fasthttp.RequestCtx, codecs codecRegistry, fieldVal reflect.Value) error {
 	contentType := string(ctx.Request.Header.ContentType())
 
-	switch {
-	case strings.HasPrefix(contentType, "application/json"):
-		return json.Unmarshal(ctx.Request.Body(), fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "multipart/form-data"):
-		return parseMultipartForm(ctx, fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
-		return parseFormURLEncoded(ctx, fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "text/plain"):
-		fieldVal.SetString(string(ctx.Request.Body()))
-	case strings.HasPrefix(contentType, "application/xml"), strings.HasPrefix(contentType, "text/xml"):
-		return xml.Unmarshal(ctx.Request.Body(), fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "application/octet-stream"):
-		return parseOctetStream(ctx, fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "text/html"):
-		fieldVal.SetString(string(ctx.Request.Body()))
-	case strings.HasPrefix(contentType, "application/pdf"):
-		return parseBinaryData(ctx, fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "application/zip"):
-		return parseBinaryData(ctx, fieldVal.Addr().Interface())
-	case strings.HasPrefix(contentType, "image/"):
-		return parseBinaryData(ctx, fieldVal.Addr().Interface())
-	default:
+	codec, ok := codecs.lookup(contentType)
+	if !ok {
 		return fmt.Errorf("unsupported content type: %s", contentType)
 	}
 
-	return nil
+	return codec.Decode(ctx, fieldVal.Addr().Interface())
 }
 
 func parseFormURLEncoded(ctx *fasthttp.RequestCtx, dst any) error {
@@ -131,7 +68,8 @@ func parseFormURLEncoded(ctx *fasthttp.RequestCtx, dst any) error {
 	data := make(map[string]any)
 
 	formData.VisitAll(func(key, value []byte) {
-		data[string(key)] = string(value)
+		values, _ := data[string(key)].([]string)
+		data[string(key)] = append(values, string(value))
 	})
 
 	return mapToStruct(data, dst)
@@ -147,7 +85,7 @@ func parseMultipartForm(ctx *fasthttp.RequestCtx, dst any) error {
 
 	for key, values := range mr.Value {
 		if len(values) > 0 {
-			data[key] = values[0]
+			data[key] = values
 		}
 	}
 
@@ -165,10 +103,6 @@ func parseMultipartForm(ctx *fasthttp.RequestCtx, dst any) error {
 	return mapToStruct(data, dst)
 }
 
-func parseOctetStream(ctx *fasthttp.RequestCtx, dst any) error {
-	return parseBinaryData(ctx, dst)
-}
-
 func parseBinaryData(ctx *fasthttp.RequestCtx, dst any) error {
 	body := ctx.Request.Body()
 	fieldVal := reflect.ValueOf(dst).Elem()
@@ -194,6 +128,11 @@ func mapToStruct(data map[string]any, dst any) error {
 		}
 
 		if value, exists := data[key]; exists {
+			// repeated form values are bound to slices, other fields get the first one
+			if values, ok := value.([]string); ok && !isArrayParam(field.Type) {
+				value = values[0]
+			}
+
 			if err := setFieldValue(fieldVal, value); err != nil {
 				return err
 			}
@@ -245,10 +184,14 @@ func setFieldValue(fieldVal reflect.Value, valueStr any) error {
 		fieldVal.SetBool(boolValue)
 
 	case reflect.Slice, reflect.Array:
-		if fieldVal.Type().Elem().Kind() == reflect.Uint8 {
+		if values, ok := valueStr.([]string); ok {
+			return setSliceValue(fieldVal, values)
+		}
+
+		if fieldVal.Kind() == reflect.Slice && fieldVal.Type().Elem().Kind() == reflect.Uint8 {
 			fieldVal.SetBytes([]byte(valueStr.(string)))
 		} else {
-			return fmt.Errorf("unsupported slice type %s", fieldVal.Type().Elem().Kind())
+			return setSliceValue(fieldVal, []string{valueStr.(string)})
 		}
 
 	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func,
@@ -261,6 +204,35 @@ func setFieldValue(fieldVal reflect.Value, valueStr any) error {
 	return nil
 }
 
+// setSliceValue sets each value to an element of a slice or an array
+func setSliceValue(fieldVal reflect.Value, values []string) error {
+	if fieldVal.Kind() == reflect.Array {
+		if len(values) > fieldVal.Len() {
+			return fmt.Errorf("too many values for array of length %d", fieldVal.Len())
+		}
+
+		for i, value := range values {
+			if err := setFieldValue(fieldVal.Index(i), value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	slice := reflect.MakeSlice(fieldVal.Type(), len(values), len(values))
+
+	for i, value := range values {
+		if err := setFieldValue(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+
+	fieldVal.Set(slice)
+
+	return nil
+}
+
 func setIntValue(fieldVal reflect.Value, valueStr string) error {
 	intValue, err := strconv.ParseInt(valueStr, 10, fieldVal.Type().Bits())
 	if err != nil {
@@ -294,37 +266,32 @@ func setFloatValue(fieldVal reflect.Value, valueStr string) error {
 	return nil
 }
 
-func buildRegex(route string) *regexp.Regexp {
-	re := regexp.MustCompile(`:[^/]+`)
-	pattern := re.ReplaceAllString(route, `([^/]+)`)
-	pattern = "^" + pattern + "$"
-
-	return regexp.MustCompile(pattern)
-}
-
+// extractParams returns the path parameters of a request path matching a route path, e.g. /foo/:id.
+// Routed requests read them from Fiber instead, see fiberHandler.
 func extractParams(path string, reqPath string) map[string]string {
-	var paramNames []string
+	segments := strings.Split(path, "/")
+	reqSegments := strings.Split(reqPath, "/")
 
-	for _, segment := range strings.Split(path, "/") {
-		if strings.HasPrefix(segment, ":") {
-			paramNames = append(paramNames, segment[1:])
-		}
-	}
-
-	if len(paramNames) == 0 {
+	if len(segments) != len(reqSegments) {
 		return nil
 	}
 
-	re := buildRegex(path)
+	var params map[string]string
 
-	matches := re.FindStringSubmatch(reqPath)
-	if matches == nil {
-		return nil
-	}
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			if reqSegments[i] == "" {
+				return nil
+			}
+
+			if params == nil {
+				params = make(map[string]string)
+			}
 
-	params := make(map[string]string)
-	for i, name := range paramNames {
-		params[name] = matches[i+1]
+			params[name] = reqSegments[i]
+		} else if segment != reqSegments[i] {
+			return nil
+		}
 	}
 
 	return params
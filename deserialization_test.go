@@ -30,7 +30,7 @@ func (suite *DeserializerTestSuite) TestDeserialize() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(nil, val, nil)
+	err := deserialize(nil, defaultCodecs(), val, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -68,7 +68,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyMultipart() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -87,7 +87,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyMultipartError() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -115,7 +115,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyApplicationOctetStrea
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -141,7 +141,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyApplicationOctetStrea
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -169,7 +169,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyApplicationXWwwFormUr
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -193,7 +193,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyTextHTML() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -217,7 +217,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyApplicationPDF() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -241,7 +241,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyApplicationZip() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -265,7 +265,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyImage() {
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -289,7 +289,7 @@ func (suite *DeserializerTestSuite) TestDeserializeWithBodyUnsupportedContentTyp
 
 	val := reflect.ValueOf(&test).Elem()
 
-	err := deserialize(c.RequestContext(), val, nil)
+	err := deserialize(c.RequestContext(), defaultCodecs(), val, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -408,6 +408,14 @@ func (suite *DeserializerTestSuite) TestSetFieldValue() {
 	assert.Error(suite.T(), err)
 
 	err = setFieldValue(val.Field(15), "1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []int{1}, test.Slice)
+
+	err = setFieldValue(val.Field(15), []string{"1", "2"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []int{1, 2}, test.Slice)
+
+	err = setFieldValue(val.Field(15), "a")
 	assert.Error(suite.T(), err)
 
 	err = setFieldValue(val.Field(16), "1,2,3")
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package lite
+
+import "net"
+
+// watchDisconnect does not detect the disconnection of the clients on this platform
+func watchDisconnect(_ net.Conn, _ func()) func() {
+	return func() {}
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package lite
+
+import (
+	stderrors "errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// watchDisconnect calls disconnected when the client closes conn, until the returned function is called.
+// The connection is peeked, so that the next pipelined request is left to the server.
+// Connections without file descriptor, e.g. TLS connections, are not watched.
+// A client half-closing the connection (shutting down its write side once its request is sent)
+// cannot be told apart from a disconnected one, and is reported as disconnected.
+func watchDisconnect(conn net.Conn, disconnected func()) func() {
+	sysConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {}
+	}
+
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		closed := false
+		buf := make([]byte, 1)
+
+		// waits until the connection is readable, or its read deadline passes
+		err := rawConn.Read(func(fd uintptr) bool {
+			n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+			if stderrors.Is(err, syscall.EAGAIN) || stderrors.Is(err, syscall.EINTR) {
+				return false
+			}
+
+			// the client either sent its next request, or closed the connection
+			closed = n == 0 || err != nil
+
+			return true
+		})
+
+		if err == nil && closed {
+			disconnected()
+		}
+	}()
+
+	return func() {
+		// unblocks the watcher, then removes the deadline: the server sets its own before its next read, if any
+		_ = conn.SetReadDeadline(time.Unix(1, 0))
+		<-done
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+}
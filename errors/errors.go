@@ -7,8 +7,16 @@ import (
 )
 
 type HTTPError struct {
-	ID      string `form:"id"      json:"id"      xml:"id"`
-	Status  int    `form:"status"  json:"status"  xml:"status"`
+	ID      string       `form:"id"      json:"id"               xml:"id"`
+	Status  int          `form:"status"  json:"status"           xml:"status"`
+	Message string       `form:"message" json:"message"          xml:"message"`
+	Errors  []FieldError `form:"errors"  json:"errors,omitempty" xml:"errors>error,omitempty"`
+}
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `form:"field"   json:"field"   xml:"field"`
+	In      string `form:"in"      json:"in"      xml:"in"`
 	Message string `form:"message" json:"message" xml:"message"`
 }
 
@@ -38,6 +46,8 @@ func (e HTTPError) Description() string {
 		return "Not Found"
 	case http.StatusConflict:
 		return "Conflict"
+	case http.StatusUnprocessableEntity:
+		return "Unprocessable Entity"
 	case http.StatusInternalServerError:
 		return "Internal Server Error"
 	default:
@@ -65,6 +75,12 @@ var DefaultErrorContentTypeResponses = []string{
 	"multipart/form-data",
 }
 
+// ProblemDetailsContentTypeResponses are the content types of the error responses in the RFC 9457 format
+var ProblemDetailsContentTypeResponses = []string{
+	ProblemJSONContentType,
+	ProblemXMLContentType,
+}
+
 func NewInternalServerError(message ...string) HTTPError {
 	if len(message) > 0 {
 		return newErrorResponse(uuid.NewString(), http.StatusInternalServerError, message[0])
@@ -97,6 +113,15 @@ func NewUnauthorizedError(message ...string) HTTPError {
 	return DefaultErrorResponses[http.StatusUnauthorized]
 }
 
+// NewForbiddenError returns a Forbidden error, e.g. when the credentials of the request lack a required scope
+func NewForbiddenError(message ...string) HTTPError {
+	if len(message) > 0 {
+		return newErrorResponse(uuid.NewString(), http.StatusForbidden, message[0])
+	}
+
+	return newErrorResponse(uuid.NewString(), http.StatusForbidden, "Forbidden")
+}
+
 func NewConflictError(message ...string) HTTPError {
 	if len(message) > 0 {
 		return newErrorResponse(uuid.NewString(), http.StatusConflict, message[0])
@@ -105,6 +130,14 @@ func NewConflictError(message ...string) HTTPError {
 	return DefaultErrorResponses[http.StatusConflict]
 }
 
+// NewValidationError returns an Unprocessable Entity error listing every invalid field
+func NewValidationError(fieldErrors ...FieldError) HTTPError {
+	err := newErrorResponse(uuid.NewString(), http.StatusUnprocessableEntity, "Validation failed")
+	err.Errors = fieldErrors
+
+	return err
+}
+
 func NewError(status int, message ...string) HTTPError {
 	if len(message) > 0 {
 		return newErrorResponse(uuid.NewString(), status, message[0])
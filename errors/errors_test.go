@@ -166,6 +166,24 @@ func TestNewUnauthorizedError(t *testing.T) {
 	}
 }
 
+func TestNewForbiddenError(t *testing.T) {
+	message := "test forbidden"
+	err := NewForbiddenError(message)
+
+	if err.Status != http.StatusForbidden {
+		t.Errorf("expected %v, got %v", http.StatusForbidden, err.Status)
+	}
+
+	if err.Message != message {
+		t.Errorf("expected %v, got %v", message, err.Message)
+	}
+
+	err = NewForbiddenError()
+	if err.Status != http.StatusForbidden {
+		t.Errorf("expected %v, got %v", http.StatusForbidden, err.Status)
+	}
+}
+
 func TestNewConflictError(t *testing.T) {
 	message := "test conflict"
 	err := NewConflictError(message)
@@ -202,3 +220,23 @@ func TestNewError(t *testing.T) {
 		t.Errorf("expected %v, got %v", status, err.Status)
 	}
 }
+
+func TestNewValidationError(t *testing.T) {
+	err := NewValidationError(
+		FieldError{Field: "limit", In: "query", Message: "must be at most 100"},
+		FieldError{Field: "name", In: "body", Message: "is required"},
+	)
+
+	if err.Status != http.StatusUnprocessableEntity {
+		t.Errorf("expected %v, got %v", http.StatusUnprocessableEntity, err.Status)
+	}
+	if err.Description() != "Unprocessable Entity" {
+		t.Errorf("expected %v, got %v", "Unprocessable Entity", err.Description())
+	}
+	if len(err.Errors) != 2 {
+		t.Fatalf("expected 2 field errors, got %d", len(err.Errors))
+	}
+	if err.Errors[0].Field != "limit" || err.Errors[1].In != "body" {
+		t.Errorf("unexpected field errors %v", err.Errors)
+	}
+}
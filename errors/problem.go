@@ -0,0 +1,186 @@
+package errors
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+const (
+	// ProblemJSONContentType is the media type of problem details serialized in JSON (RFC 9457)
+	ProblemJSONContentType = "application/problem+json"
+	// ProblemXMLContentType is the media type of problem details serialized in XML (RFC 9457)
+	ProblemXMLContentType = "application/problem+xml"
+
+	problemXMLNamespace = "urn:ietf:rfc:7807"
+	problemDefaultType  = "about:blank"
+)
+
+// ProblemDetails is an error in the RFC 9457 "Problem Details for HTTP APIs" format.
+// ID and Errors are extension members carried over from HTTPError,
+// any other extension member can be set in Extensions.
+type ProblemDetails struct {
+	Type       string         `json:"type"               xml:"type"`
+	Title      string         `json:"title"              xml:"title"`
+	Status     int            `json:"status"             xml:"status"`
+	Detail     string         `json:"detail,omitempty"   xml:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty" xml:"instance,omitempty"`
+	ID         string         `json:"id,omitempty"       xml:"id,omitempty"`
+	Errors     []FieldError   `json:"errors,omitempty"   xml:"errors>error,omitempty"`
+	Extensions map[string]any `json:"-"                  xml:"-"`
+}
+
+// NewProblemDetails returns problem details of the given status.
+// The type defaults to "about:blank" and the title to the HTTP status text.
+func NewProblemDetails(status int, detail string) ProblemDetails {
+	return ProblemDetails{
+		Type:   problemDefaultType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ProblemDetails converts the HTTPError to the RFC 9457 format
+func (e HTTPError) ProblemDetails() ProblemDetails {
+	problem := NewProblemDetails(e.Status, e.Message)
+	problem.ID = e.ID
+	problem.Errors = e.Errors
+
+	return problem
+}
+
+func (p ProblemDetails) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	return p.Title
+}
+
+func (p ProblemDetails) StatusCode() int {
+	return p.Status
+}
+
+// HTTPError converts the problem details to an HTTPError, dropping the members HTTPError can't carry
+func (p ProblemDetails) HTTPError() HTTPError {
+	err := newErrorResponse(p.ID, p.Status, p.Error())
+	err.Errors = p.Errors
+
+	return err
+}
+
+// WithExtension returns a copy of the problem details with an additional extension member
+func (p ProblemDetails) WithExtension(key string, value any) ProblemDetails {
+	extensions := make(map[string]any, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		extensions[k] = v
+	}
+
+	extensions[key] = value
+	p.Extensions = extensions
+
+	return p
+}
+
+type problemDetails ProblemDetails
+
+// MarshalJSON serializes the extension members next to the standard members
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemDetails(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	members := make(map[string]any, len(p.Extensions))
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	if err = json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON reads the standard members, every other member is stored in Extensions
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*problemDetails)(p)); err != nil {
+		return err
+	}
+
+	var members map[string]any
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for _, key := range []string{"type", "title", "status", "detail", "instance", "id", "errors"} {
+		delete(members, key)
+	}
+
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+
+	return nil
+}
+
+// MarshalXML serializes the problem details in the urn:ietf:rfc:7807 namespace,
+// extension members are written as child elements.
+func (p ProblemDetails) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: problemXMLNamespace, Local: "problem"}}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, member := range []struct {
+		name  string
+		value any
+		empty bool
+	}{
+		{"type", p.Type, false},
+		{"title", p.Title, false},
+		{"status", p.Status, false},
+		{"detail", p.Detail, p.Detail == ""},
+		{"instance", p.Instance, p.Instance == ""},
+		{"id", p.ID, p.ID == ""},
+	} {
+		if member.empty {
+			continue
+		}
+
+		if err := e.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Local: member.name}}); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Errors) > 0 {
+		errs := struct {
+			Errors []FieldError `xml:"error"`
+		}{Errors: p.Errors}
+
+		if err := e.EncodeElement(errs, xml.StartElement{Name: xml.Name{Local: "errors"}}); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(p.Extensions))
+	for key := range p.Extensions {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := e.EncodeElement(fmt.Sprint(p.Extensions[key]), xml.StartElement{Name: xml.Name{Local: key}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
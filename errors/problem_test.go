@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPError_ProblemDetails(t *testing.T) {
+	err := NewValidationError(FieldError{Field: "name", In: "body", Message: "is required"})
+	problem := err.ProblemDetails()
+
+	if problem.Type != "about:blank" {
+		t.Errorf("expected %v, got %v", "about:blank", problem.Type)
+	}
+	if problem.Title != "Unprocessable Entity" {
+		t.Errorf("expected %v, got %v", "Unprocessable Entity", problem.Title)
+	}
+	if problem.Status != http.StatusUnprocessableEntity {
+		t.Errorf("expected %v, got %v", http.StatusUnprocessableEntity, problem.Status)
+	}
+	if problem.Detail != err.Message || problem.ID != err.ID || len(problem.Errors) != 1 {
+		t.Errorf("unexpected problem details %v", problem)
+	}
+
+	back := problem.HTTPError()
+	if back.ID != err.ID || back.Status != err.Status || back.Message != err.Message || len(back.Errors) != 1 {
+		t.Errorf("unexpected http error %v", back)
+	}
+}
+
+func TestProblemDetails_Error(t *testing.T) {
+	problem := NewProblemDetails(http.StatusNotFound, "")
+	if problem.Error() != "Not Found" {
+		t.Errorf("expected %v, got %v", "Not Found", problem.Error())
+	}
+
+	problem.Detail = "user 42 not found"
+	if problem.Error() != "user 42 not found" {
+		t.Errorf("expected %v, got %v", "user 42 not found", problem.Error())
+	}
+	if problem.StatusCode() != http.StatusNotFound {
+		t.Errorf("expected %v, got %v", http.StatusNotFound, problem.StatusCode())
+	}
+}
+
+func TestProblemDetails_JSON(t *testing.T) {
+	problem := NewProblemDetails(http.StatusForbidden, "Your current balance is 30, but that costs 50.").
+		WithExtension("balance", 30).
+		WithExtension("accounts", []string{"/account/12345"})
+	problem.Type = "https://example.com/probs/out-of-credit"
+	problem.Instance = "/account/12345/msgs/abc"
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"accounts":["/account/12345"],"balance":30,"detail":"Your current balance is 30, but that costs 50.",` +
+		`"instance":"/account/12345/msgs/abc","status":403,"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`
+	if string(data) != expected {
+		t.Errorf("expected %v, got %v", expected, string(data))
+	}
+
+	var decoded ProblemDetails
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Type != problem.Type || decoded.Status != problem.Status || decoded.Instance != problem.Instance {
+		t.Errorf("unexpected problem details %v", decoded)
+	}
+	if decoded.Extensions["balance"] != float64(30) || len(decoded.Extensions) != 2 {
+		t.Errorf("unexpected extensions %v", decoded.Extensions)
+	}
+}
+
+func TestProblemDetails_JSON_WithoutExtensions(t *testing.T) {
+	data, err := json.Marshal(NewProblemDetails(http.StatusNotFound, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"about:blank","title":"Not Found","status":404}`
+	if string(data) != expected {
+		t.Errorf("expected %v, got %v", expected, string(data))
+	}
+
+	var decoded ProblemDetails
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Extensions != nil {
+		t.Errorf("expected no extensions, got %v", decoded.Extensions)
+	}
+}
+
+func TestProblemDetails_XML(t *testing.T) {
+	problem := NewProblemDetails(http.StatusBadRequest, "invalid").WithExtension("balance", 30)
+	problem.ID = "id"
+	problem.Errors = []FieldError{{Field: "name", In: "body", Message: "is required"}}
+
+	data, err := xml.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Bad Request</title><status>400</status>` +
+		`<detail>invalid</detail><id>id</id><errors><error><field>name</field><in>body</in><message>is required</message></error>` +
+		`</errors><balance>30</balance></problem>`
+	if string(data) != expected {
+		t.Errorf("expected %v, got %v", expected, string(data))
+	}
+}
@@ -0,0 +1,213 @@
+package lite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETagMode selects the entity tags generated for the responses of a route, see Route.ETag
+type ETagMode int
+
+const (
+	// NoETag generates no entity tag, the default
+	NoETag ETagMode = iota
+
+	// StrongETag generates strong entity tags, e.g. "5d41402abc4b2a76b9719d911017c592",
+	// which can be checked by Context.IfMatch before modifying a resource
+	StrongETag
+
+	// WeakETag generates weak entity tags, e.g. W/"5d41402abc4b2a76b9719d911017c592",
+	// only used to answer 304 Not Modified
+	WeakETag
+)
+
+// etagOf returns the entity tag of a serialized body
+func (m ETagMode) etagOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	if m == WeakETag {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// ETagOf returns the entity tag of body serialized in JSON, the one generated for the JSON responses
+// of the routes with the same mode. A handler modifying a resource passes the entity tag of its current
+// representation to Context.IfMatch:
+//
+//	etag, err := lite.ETagOf(current, lite.StrongETag)
+//	if err != nil {
+//		return Item{}, err
+//	}
+//
+//	if err := c.IfMatch(etag); err != nil {
+//		return Item{}, err
+//	}
+func ETagOf(body any, mode ETagMode) (string, error) {
+	var buf bytes.Buffer
+
+	// encoded as the JSON responses, with their trailing newline
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return "", err
+	}
+
+	return mode.etagOf(buf.Bytes()), nil
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists etag.
+// The strong comparison never matches weak entity tags (RFC 9110 section 8.8.3.2).
+func etagMatches(header, etag string, strong bool) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		switch {
+		case candidate == "*":
+			return true
+		case strong:
+			if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+		case strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// IfMatch checks the If-Match header of the request against etag, the entity tag of the current representation
+// of the resource the handler is about to modify, e.g. computed with ETagOf.
+// It returns a 412 Precondition Failed error when the header does not match, and a 428 Precondition Required error
+// when the request has none and the route requires it (see Route.RequireIfMatch).
+func (c *ContextNoRequest) IfMatch(etag string) error {
+	ifMatch := c.ctx.Get(HeaderIfMatch)
+	if ifMatch == "" {
+		if c.requireIfMatch {
+			return errors.NewError(StatusPreconditionRequired, "the If-Match header is required")
+		}
+
+		return nil
+	}
+
+	if !etagMatches(ifMatch, etag, true) {
+		return errors.NewError(StatusPreconditionFailed, "the resource has been modified")
+	}
+
+	return nil
+}
+
+// requireIfMatchHeader rejects the PUT, PATCH and DELETE requests without If-Match header of a route requiring it,
+// before its handler runs
+func (r *registeredRoute) requireIfMatchHeader(c *fiber.Ctx) error {
+	if !r.requireIfMatch || c.Get(HeaderIfMatch) != "" {
+		return nil
+	}
+
+	switch c.Method() {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return errors.NewError(StatusPreconditionRequired, "the If-Match header is required")
+	}
+
+	return nil
+}
+
+// writeETag tags the successful response of a route generating entity tags, unless the handler tagged it itself,
+// and answers 304 Not Modified to the GET and HEAD requests whose If-None-Match header matches the tag
+func (r *registeredRoute) writeETag(c *fiber.Ctx) {
+	status := c.Response().StatusCode()
+	if r.etag == NoETag || status < http.StatusOK || status >= http.StatusMultipleChoices || c.Response().IsBodyStream() {
+		return
+	}
+
+	etag := string(c.Response().Header.Peek(HeaderETag))
+	if etag == "" {
+		etag = r.etag.etagOf(c.Response().Body())
+		c.Set(HeaderETag, etag)
+	}
+
+	method := c.Method()
+	if (method == http.MethodGet || method == http.MethodHead) && etagMatches(c.Get(HeaderIfNoneMatch), etag, false) {
+		c.Status(http.StatusNotModified)
+		c.Response().ResetBody()
+	}
+}
+
+// documentETag documents the entity tag of the successful response of a route and the conditional requests
+// it supports: If-None-Match on GET and HEAD routes, If-Match on PUT, PATCH and DELETE routes
+func documentETag(s *App, operation *openapi3.Operation, method string, statusCode int, route *registeredRoute) error {
+	if route.etag != NoETag {
+		if response := operation.Responses.Value(strconv.Itoa(statusCode)); response != nil {
+			if response.Value.Headers == nil {
+				response.Value.Headers = openapi3.Headers{}
+			}
+
+			response.Value.Headers[HeaderETag] = newHeaderRef()
+		}
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if route.etag == NoETag {
+			return nil
+		}
+
+		addHeaderParameter(operation, HeaderIfNoneMatch, false)
+
+		notModified := openapi3.NewResponse().WithDescription("Not Modified")
+		notModified.Headers = headerRefs(HeaderETag)
+		operation.AddResponse(http.StatusNotModified, notModified)
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		if route.etag == NoETag && !route.requireIfMatch {
+			return nil
+		}
+
+		addHeaderParameter(operation, HeaderIfMatch, route.requireIfMatch)
+
+		preconditionFailed, err := s.createErrorResponse(errors.NewError(StatusPreconditionFailed))
+		if err != nil {
+			return err
+		}
+
+		operation.AddResponse(http.StatusPreconditionFailed, preconditionFailed)
+
+		if route.requireIfMatch {
+			preconditionRequired, err := s.createErrorResponse(errors.NewError(StatusPreconditionRequired))
+			if err != nil {
+				return err
+			}
+
+			operation.AddResponse(http.StatusPreconditionRequired, preconditionRequired)
+		}
+	}
+
+	return nil
+}
+
+// addHeaderParameter documents a string header parameter of an operation, once
+func addHeaderParameter(operation *openapi3.Operation, name string, required bool) {
+	if parameter := operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, name); parameter != nil {
+		parameter.Required = parameter.Required || required
+
+		return
+	}
+
+	parameter := openapi3.NewHeaderParameter(name).WithSchema(openapi3.NewStringSchema())
+	parameter.Required = required
+
+	operation.AddParameter(parameter)
+}
@@ -0,0 +1,193 @@
+package lite
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type versionedItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type updateItemRequest struct {
+	ID   string        `lite:"path=id"`
+	Body versionedItem `lite:"req=body"`
+}
+
+func TestETag(t *testing.T) {
+	app := New()
+
+	item := versionedItem{ID: "1", Name: "first"}
+
+	items := app.Group("/items").ETag(StrongETag)
+
+	Get(items, "/:id", func(_ *ContextNoRequest) (versionedItem, error) {
+		return item, nil
+	})
+
+	Put(items, "/:id", func(c *ContextWithRequest[updateItemRequest]) (versionedItem, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return versionedItem{}, err
+		}
+
+		etag, err := ETagOf(item, StrongETag)
+		if err != nil {
+			return versionedItem{}, err
+		}
+
+		if err := c.IfMatch(etag); err != nil {
+			return versionedItem{}, err
+		}
+
+		item = req.Body
+
+		return item, nil
+	}).RequireIfMatch()
+
+	Get(app, "/versions/:id", func(c *ContextNoRequest) (versionedItem, error) {
+		c.Set(HeaderETag, `"v1"`)
+
+		return item, nil
+	}).ETag(WeakETag)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/items/1", nil))
+	require.NoError(t, err)
+
+	etag := resp.Header.Get(HeaderETag)
+	expected, err := ETagOf(item, StrongETag)
+	require.NoError(t, err)
+	assert.Equal(t, expected, etag)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "not modified", method: "GET", path: "/items/1", headers: map[string]string{HeaderIfNoneMatch: etag}, status: 304},
+		{name: "modified", method: "GET", path: "/items/1", headers: map[string]string{HeaderIfNoneMatch: `"other"`}, status: 200},
+		{name: "handler etag", method: "GET", path: "/versions/1", headers: map[string]string{HeaderIfNoneMatch: `W/"v1"`}, status: 304},
+		{name: "precondition required", method: "PUT", path: "/items/1", status: 428},
+		{name: "precondition failed", method: "PUT", path: "/items/1", headers: map[string]string{HeaderIfMatch: `"other"`}, status: 412},
+		{name: "weak precondition", method: "PUT", path: "/items/1", headers: map[string]string{HeaderIfMatch: "W/" + etag}, status: 412},
+		{name: "updated", method: "PUT", path: "/items/1", headers: map[string]string{HeaderIfMatch: etag}, status: 200},
+		{name: "updated concurrently", method: "PUT", path: "/items/1", headers: map[string]string{HeaderIfMatch: etag}, status: 412},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"id":"1","name":"second"}`))
+			req.Header.Set(HeaderContentType, "application/json")
+
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			if tt.status == 304 {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Empty(t, body)
+				assert.NotEmpty(t, resp.Header.Get(HeaderETag))
+			}
+		})
+	}
+
+	get := app.OpenAPISpec.Paths.Find("/items/{id}").Get
+	assert.NotNil(t, get.Parameters.GetByInAndName("header", HeaderIfNoneMatch))
+	assert.NotNil(t, get.Responses.Value("304"))
+	assert.Contains(t, get.Responses.Value("200").Value.Headers, HeaderETag)
+
+	put := app.OpenAPISpec.Paths.Find("/items/{id}").Put
+	assert.True(t, put.Parameters.GetByInAndName("header", HeaderIfMatch).Required)
+	assert.NotNil(t, put.Responses.Value("412"))
+	assert.NotNil(t, put.Responses.Value("428"))
+}
+
+func TestRequireIfMatch_WithoutCheck(t *testing.T) {
+	app := New()
+
+	called := false
+
+	Put(app, "/items/:id", func(_ *ContextNoRequest) (string, error) {
+		called = true
+
+		return "updated", nil
+	}).RequireIfMatch()
+
+	Get(app, "/items/:id", func(_ *ContextNoRequest) (string, error) {
+		return "item", nil
+	}).RequireIfMatch()
+
+	resp, err := app.Test(httptest.NewRequest("PUT", "/items/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 428, resp.StatusCode)
+	assert.False(t, called)
+
+	req := httptest.NewRequest("PUT", "/items/1", nil)
+	req.Header.Set(HeaderIfMatch, `"v1"`)
+
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, called)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/items/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestContext_IfMatch(t *testing.T) {
+	app := New()
+
+	Delete(app, "/items/:id", func(c *ContextNoRequest) (struct{}, error) {
+		return struct{}{}, c.IfMatch(`"v2"`)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/items/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+
+	req := httptest.NewRequest("DELETE", "/items/1", nil)
+	req.Header.Set(HeaderIfMatch, `"v1"`)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 412, resp.StatusCode)
+
+	assert.Nil(t, app.OpenAPISpec.Paths.Find("/items/{id}").Delete.Responses.Value("412"))
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		strong bool
+		match  bool
+	}{
+		{header: `"a"`, etag: `"a"`, strong: true, match: true},
+		{header: `"b", "a"`, etag: `"a"`, strong: true, match: true},
+		{header: `*`, etag: `"a"`, strong: true, match: true},
+		{header: `W/"a"`, etag: `"a"`, strong: true, match: false},
+		{header: `"a"`, etag: `W/"a"`, strong: true, match: false},
+		{header: `W/"a"`, etag: `"a"`, strong: false, match: true},
+		{header: `"b"`, etag: `"a"`, strong: false, match: false},
+		{header: `"a"`, etag: "", strong: false, match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header+" "+tt.etag, func(t *testing.T) {
+			assert.Equal(t, tt.match, etagMatches(tt.header, tt.etag, tt.strong))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Code generated by lite clientgen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+
+	"github.com/go-lite/lite/client"
+	"github.com/go-lite/lite/examples/basic/parameters"
+	"github.com/go-lite/lite/examples/basic/returns"
+)
+
+// Client is a typed client of the "OpenAPI" API
+type Client struct {
+	*client.Client
+}
+
+// New returns a Client of the server at baseURL
+func New(baseURL string, options ...client.Option) *Client {
+	return &Client{Client: client.New(baseURL, options...)}
+}
+
+// GetExampleByName calls GET /example/:name
+func (c *Client) GetExampleByName(ctx context.Context, request parameters.GetReq) (returns.GetResponse, error) {
+	return client.Do[returns.GetResponse](ctx, c.Client, "GET", "/example/:name", request)
+}
+
+// CreateExample calls POST /example/:id
+func (c *Client) CreateExample(ctx context.Context, request parameters.CreateReq) (returns.CreateResponse, error) {
+	return client.Do[returns.CreateResponse](ctx, c.Client, "POST", "/example/:id", request)
+}
+
+// GetExample calls GET /example
+func (c *Client) GetExample(ctx context.Context, request parameters.GetArrayReq) ([]returns.Ret, error) {
+	return client.Do[[]returns.Ret](ctx, c.Client, "GET", "/example", request)
+}
@@ -1,8 +1,8 @@
 package parameters
 
 type CreateBody struct {
-	FirstName string  `json:"first_name"`
-	LastName  *string `json:"last_name"`
+	FirstName string  `json:"first_name" validate:"required,max=64"`
+	LastName  *string `json:"last_name"  validate:"max=64"`
 }
 
 type CreateReq struct {
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-lite/lite/examples/basic/parameters"
+	"github.com/go-lite/lite/examples/basic/returns"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+
+	"github.com/go-lite/lite"
+)
+
+// Define example handler
+func getHandler(c *lite.ContextWithRequest[parameters.GetReq]) (returns.GetResponse, error) {
+	request, err := c.Requests()
+	if err != nil {
+		return returns.GetResponse{}, err
+	}
+
+	if request.Params == "test" {
+		return returns.GetResponse{}, errors.New("test is not valid name")
+	}
+
+	return returns.GetResponse{
+		Message: "Hello World!, " + request.Params,
+	}, nil
+}
+
+func postHandler(c *lite.ContextWithRequest[parameters.CreateReq]) (returns.CreateResponse, error) {
+	request, err := c.Requests()
+	if err != nil {
+		return returns.CreateResponse{}, err
+	}
+
+	return returns.CreateResponse{
+		ID:        request.ID,
+		FirstName: request.Body.FirstName,
+		LastName:  request.Body.LastName,
+	}, nil
+}
+
+func getArrayHandler(_ *lite.ContextWithRequest[parameters.GetArrayReq]) (returns.GetArrayReturnsResponse, error) {
+	res := make([]returns.Ret, 0)
+
+	value := "value"
+	res = append(res, returns.Ret{
+		Message: "Hello World!",
+		Embed: returns.Embed{
+			Key:        "key",
+			ValueEmbed: &value,
+		},
+	},
+		returns.Ret{
+			Message: "Hello World 2!",
+			Embed: returns.Embed{
+				Key: "key2",
+			},
+		},
+	)
+
+	return res, nil
+}
+
+// NewApp registers the routes of the example API
+func NewApp() *lite.App {
+	app := lite.New()
+
+	app.Use(logger.New())
+	app.Use(recover.New())
+
+	// the example accepts any credentials of its security schemes
+	app.AddAuthenticator("Basic", lite.BasicAuthenticator(func(_ context.Context, username, _ string) (lite.Identity, error) {
+		return lite.Identity{Subject: username}, nil
+	}))
+	app.AddAuthenticator("Authorization", lite.APIKeyAuthenticator(func(context.Context, string) (lite.Identity, error) {
+		return lite.Identity{}, nil
+	}))
+
+	lite.Get(app, "/example/:name", getHandler).SetResponseContentType("application/xml")
+
+	lite.Post(app, "/example/:id", postHandler).
+		OperationID("createExample").
+		Description("Create example").
+		AddTags("example")
+
+	lite.Get(app, "/example", getArrayHandler)
+
+	app.AddServer("http://localhost:6001", "example server")
+
+	return app
+}
@@ -5,7 +5,6 @@ import (
 	"log"
 	"mime/multipart"
 	"os"
-	"path/filepath"
 
 	"github.com/go-lite/lite"
 	"github.com/go-lite/lite/errors"
@@ -66,39 +65,9 @@ func main() {
 
 	app.AddServer("http://localhost:9999", "example server")
 
-	yamlBytes, err := app.SaveOpenAPISpec()
-	if err != nil {
-		log.Fatal(err)
-	}
+	app.OpenAPIConfig.YamlURL = "./examples/file/api/openapi.yaml"
 
-	// Ensure the directory exists
-	err = os.MkdirAll(filepath.Dir("./examples/file/api/openapi.yaml"), os.ModePerm)
-	if err != nil {
+	if err := app.Listen(":9999"); err != nil {
 		log.Fatal(err)
 	}
-
-	f, err := os.Create("./examples/file/api/openapi.yaml")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer func() {
-		closeErr := f.Close()
-		if err != nil {
-			if closeErr != nil {
-				err = closeErr
-			}
-
-			log.Fatal(err)
-		}
-	}()
-
-	_, err = f.Write(yamlBytes)
-	if err != nil {
-		return
-	}
-
-	if err = app.Listen(":9999"); err != nil {
-		return
-	}
 }
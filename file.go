@@ -0,0 +1,306 @@
+package lite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// File is a response body copied from a seekable content, e.g. an *os.File or a blob, so that clients can resume
+// their downloads:
+//   - the Range requests of a single range of bytes are answered with 206 Partial Content,
+//     or 416 Range Not Satisfiable when the range is out of the content, unless their If-Range header
+//     does not match the ETag or the ModTime of the file
+//   - the If-None-Match and If-Modified-Since requests are answered with 304 Not Modified
+//     when the file did not change
+//
+// Content is closed once copied when it is an io.Closer.
+type File struct {
+	Content     io.ReadSeeker
+	Name        string    // Name of the file downloaded by the client, inline when empty
+	ContentType string    // Content type of the response, guessed from the extension of Name when empty
+	ETag        string    // Entity tag of the content, quoted, e.g. "v1" or W/"v1" for a weak one
+	ModTime     time.Time // Modification time of the content, sent in the Last-Modified header unless zero
+}
+
+// OpenFile opens the file at path, whose ETag and ModTime are derived from its size and modification time.
+// It returns a 404 Not Found error when the file does not exist.
+func OpenFile(path string) (File, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return File{}, errors.NewNotFoundError("file not found")
+		}
+
+		return File{}, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return File{}, err
+	}
+
+	if info.IsDir() {
+		_ = file.Close()
+
+		return File{}, errors.NewNotFoundError("file not found")
+	}
+
+	return File{
+		Content:     file,
+		ContentType: mime.TypeByExtension(filepath.Ext(path)),
+		ETag:        fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// NewBlob returns the File of data, whose ETag is derived from its SHA-256 hash
+func NewBlob(data []byte, contentType string) File {
+	sum := sha256.Sum256(data)
+
+	return File{
+		Content:     bytes.NewReader(data),
+		ContentType: contentType,
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+func (File) streamContentType() string {
+	return "application/octet-stream"
+}
+
+func (File) streamSchema(*App) (*openapi3.SchemaRef, error) {
+	return openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithFormat("binary")), nil
+}
+
+func (f File) writeStream(ctx *fasthttp.RequestCtx, release func()) error {
+	// the content is read without the context of the request
+	release()
+
+	if f.Content == nil {
+		return fmt.Errorf("file %q has no content", f.Name)
+	}
+
+	size, err := f.Content.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = closeContent(f.Content)
+
+		return err
+	}
+
+	switch {
+	case f.ContentType != "":
+		ctx.SetContentType(f.ContentType)
+	case mime.TypeByExtension(filepath.Ext(f.Name)) != "":
+		ctx.SetContentType(mime.TypeByExtension(filepath.Ext(f.Name)))
+	}
+
+	if f.Name != "" {
+		ctx.Response.Header.Set(HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+			"filename": f.Name,
+		}))
+	}
+
+	ctx.Response.Header.Set(HeaderAcceptRanges, "bytes")
+
+	if f.ETag != "" {
+		ctx.Response.Header.Set(HeaderETag, f.ETag)
+	}
+
+	if !f.ModTime.IsZero() {
+		ctx.Response.Header.Set(HeaderLastModified, f.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	// the conditional and range requests only apply to the successful responses
+	if ctx.Response.StatusCode() != http.StatusOK {
+		return f.send(ctx, 0, size)
+	}
+
+	if (ctx.IsGet() || ctx.IsHead()) && f.notModified(&ctx.Request.Header) {
+		_ = closeContent(f.Content)
+
+		ctx.SetStatusCode(http.StatusNotModified)
+		ctx.ResetBody()
+
+		return nil
+	}
+
+	header := string(ctx.Request.Header.Peek(HeaderRange))
+	if header == "" || !f.rangeApplies(string(ctx.Request.Header.Peek(HeaderIfRange))) {
+		return f.send(ctx, 0, size)
+	}
+
+	start, length, ok := parseRange(header, size)
+	if !ok {
+		_ = closeContent(f.Content)
+
+		ctx.Response.Header.Set(HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10))
+		ctx.SetStatusCode(http.StatusRequestedRangeNotSatisfiable)
+		ctx.ResetBody()
+
+		return nil
+	}
+
+	if length != size {
+		ctx.SetStatusCode(http.StatusPartialContent)
+		ctx.Response.Header.Set(HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+	}
+
+	return f.send(ctx, start, length)
+}
+
+// send streams length bytes of the content from start
+func (f File) send(ctx *fasthttp.RequestCtx, start, length int64) error {
+	if _, err := f.Content.Seek(start, io.SeekStart); err != nil {
+		_ = closeContent(f.Content)
+
+		return err
+	}
+
+	ctx.SetBodyStream(struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f.Content, length), closer{f.Content}}, int(length))
+
+	return nil
+}
+
+// notModified reports whether the client already has the content, from the If-None-Match header of the request,
+// or from its If-Modified-Since header when it has no If-None-Match header (RFC 9110 section 13.2.2)
+func (f File) notModified(header *fasthttp.RequestHeader) bool {
+	if ifNoneMatch := string(header.Peek(HeaderIfNoneMatch)); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, f.ETag, false)
+	}
+
+	ifModifiedSince := string(header.Peek(HeaderIfModifiedSince))
+	if ifModifiedSince == "" || f.ModTime.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	return !f.ModTime.Truncate(time.Second).After(since)
+}
+
+// rangeApplies reports whether the Range header of a request applies, given its If-Range header:
+// the ETag or the modification time of the content the client already has part of.
+// A weak ETag never matches, as the parts of a content must be byte-for-byte identical.
+func (f File) rangeApplies(ifRange string) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return f.ETag != "" && !strings.HasPrefix(f.ETag, "W/") && ifRange == f.ETag
+	}
+
+	date, err := http.ParseTime(ifRange)
+	if err != nil || f.ModTime.IsZero() {
+		return false
+	}
+
+	return f.ModTime.Truncate(time.Second).Equal(date)
+}
+
+// parseRange returns the start and the length of the range of bytes of a Range header.
+// Headers with several ranges are ignored and the whole content is sent.
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, size, true
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	if first == "" {
+		// suffix range: the last bytes of the content
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, false
+		}
+
+		n = min(n, size)
+
+		return size - n, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+
+		end = min(end, size-1)
+	}
+
+	return start, end - start + 1, true
+}
+
+// closer closes the content of a File once it is sent, when it is an io.Closer
+type closer struct {
+	content io.Reader
+}
+
+func (c closer) Close() error {
+	return closeContent(c.content)
+}
+
+func closeContent(content io.Reader) error {
+	if c, ok := content.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// documentFile documents the validators of the successful response of a route responding with a File,
+// and its responses to the range and conditional requests
+func documentFile(operation *openapi3.Operation, response *openapi3.Response) {
+	for _, name := range []string{HeaderRange, HeaderIfRange, HeaderIfNoneMatch, HeaderIfModifiedSince} {
+		addHeaderParameter(operation, name, false)
+	}
+
+	response.Headers = headerRefs(HeaderETag, HeaderLastModified, HeaderAcceptRanges)
+
+	partial := openapi3.NewResponse().WithDescription("Partial Content").WithContent(response.Content)
+	partial.Headers = headerRefs(HeaderContentRange, HeaderETag, HeaderLastModified)
+	operation.AddResponse(http.StatusPartialContent, partial)
+
+	notModified := openapi3.NewResponse().WithDescription("Not Modified")
+	notModified.Headers = headerRefs(HeaderETag, HeaderLastModified)
+	operation.AddResponse(http.StatusNotModified, notModified)
+
+	notSatisfiable := openapi3.NewResponse().WithDescription("Range Not Satisfiable")
+	notSatisfiable.Headers = headerRefs(HeaderContentRange)
+	operation.AddResponse(http.StatusRequestedRangeNotSatisfiable, notSatisfiable)
+}
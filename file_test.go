@@ -0,0 +1,218 @@
+package lite
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closingReader struct {
+	*strings.Reader
+	closed bool
+}
+
+func (r *closingReader) Close() error {
+	r.closed = true
+
+	return nil
+}
+
+func TestFile(t *testing.T) {
+	app := New()
+
+	var content *closingReader
+
+	Get(app, "/files/report.txt", func(_ *ContextNoRequest) (File, error) {
+		content = &closingReader{Reader: strings.NewReader("0123456789")}
+
+		return File{Content: content, Name: "report.txt"}, nil
+	})
+
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		status       int
+		contentRange string
+		body         string
+	}{
+		{name: "whole file", status: 200, body: "0123456789"},
+		{name: "range", rangeHeader: "bytes=2-4", status: 206, contentRange: "bytes 2-4/10", body: "234"},
+		{name: "open range", rangeHeader: "bytes=7-", status: 206, contentRange: "bytes 7-9/10", body: "789"},
+		{name: "suffix range", rangeHeader: "bytes=-3", status: 206, contentRange: "bytes 7-9/10", body: "789"},
+		{name: "range past the end", rangeHeader: "bytes=8-20", status: 206, contentRange: "bytes 8-9/10", body: "89"},
+		{name: "several ranges", rangeHeader: "bytes=0-1,4-5", status: 200, body: "0123456789"},
+		{name: "unsatisfiable", rangeHeader: "bytes=10-", status: 416, contentRange: "bytes */10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/files/report.txt", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set(HeaderRange, tt.rangeHeader)
+			}
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.contentRange, resp.Header.Get(HeaderContentRange))
+			assert.Equal(t, "bytes", resp.Header.Get(HeaderAcceptRanges))
+			assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get(HeaderContentType))
+			assert.Equal(t, "attachment; filename=report.txt", resp.Header.Get(HeaderContentDisposition))
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.body, string(body))
+			assert.True(t, content.closed)
+		})
+	}
+
+	responses := app.OpenAPISpec.Paths.Find("/files/report.txt").Get.Responses
+	assert.NotNil(t, responses.Value("206"))
+	assert.Equal(t, "binary", responses.Value("200").Value.Content["application/octet-stream"].Schema.Value.Format)
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header string
+		start  int64
+		length int64
+		ok     bool
+	}{
+		{header: "bytes=0-0", start: 0, length: 1, ok: true},
+		{header: "bytes=-20", start: 0, length: 10, ok: true},
+		{header: "items=0-5", start: 0, length: 10, ok: true},
+		{header: "bytes=5-2"},
+		{header: "bytes=-0"},
+		{header: "bytes=a-b"},
+		{header: "bytes=5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, length, ok := parseRange(tt.header, 10)
+
+			assert.Equal(t, tt.ok, ok)
+
+			if tt.ok {
+				assert.Equal(t, tt.start, start)
+				assert.Equal(t, tt.length, length)
+			}
+		})
+	}
+}
+
+func TestFile_Conditional(t *testing.T) {
+	app := New()
+
+	modTime := time.Date(2024, time.May, 1, 10, 0, 0, 500, time.UTC)
+
+	Get(app, "/logo.png", func(_ *ContextNoRequest) (File, error) {
+		file := NewBlob([]byte("0123456789"), "image/png")
+		file.ModTime = modTime
+
+		return file, nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/logo.png", nil))
+	require.NoError(t, err)
+
+	etag := resp.Header.Get(HeaderETag)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Wed, 01 May 2024 10:00:00 GMT", resp.Header.Get(HeaderLastModified))
+	assert.Equal(t, "image/png", resp.Header.Get(HeaderContentType))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{name: "matching etag", headers: map[string]string{HeaderIfNoneMatch: `"other", ` + etag}, status: 304},
+		{name: "weak etag", headers: map[string]string{HeaderIfNoneMatch: "W/" + etag}, status: 304},
+		{name: "any etag", headers: map[string]string{HeaderIfNoneMatch: "*"}, status: 304},
+		{name: "other etag", headers: map[string]string{HeaderIfNoneMatch: `"other"`}, status: 200, body: "0123456789"},
+		{
+			name:    "etag before date",
+			headers: map[string]string{HeaderIfNoneMatch: `"other"`, HeaderIfModifiedSince: "Wed, 01 May 2024 10:00:00 GMT"},
+			status:  200,
+			body:    "0123456789",
+		},
+		{name: "not modified since", headers: map[string]string{HeaderIfModifiedSince: "Wed, 01 May 2024 10:00:00 GMT"}, status: 304},
+		{name: "modified since", headers: map[string]string{HeaderIfModifiedSince: "Wed, 01 May 2024 09:59:59 GMT"}, status: 200, body: "0123456789"},
+		{
+			name:    "if-range etag",
+			headers: map[string]string{HeaderRange: "bytes=5-", HeaderIfRange: etag},
+			status:  206,
+			body:    "56789",
+		},
+		{
+			name:    "if-range date",
+			headers: map[string]string{HeaderRange: "bytes=5-", HeaderIfRange: "Wed, 01 May 2024 10:00:00 GMT"},
+			status:  206,
+			body:    "56789",
+		},
+		{
+			name:    "if-range changed",
+			headers: map[string]string{HeaderRange: "bytes=5-", HeaderIfRange: `"other"`},
+			status:  200,
+			body:    "0123456789",
+		},
+		{
+			name:    "if-range weak",
+			headers: map[string]string{HeaderRange: "bytes=5-", HeaderIfRange: "W/" + etag},
+			status:  200,
+			body:    "0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/logo.png", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, etag, resp.Header.Get(HeaderETag))
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+
+	operation := app.OpenAPISpec.Paths.Find("/logo.png").Get
+	assert.NotNil(t, operation.Responses.Value("206"))
+	assert.NotNil(t, operation.Responses.Value("304"))
+	assert.NotNil(t, operation.Responses.Value("416"))
+	assert.Contains(t, operation.Responses.Value("200").Value.Headers, HeaderETag)
+	assert.NotNil(t, operation.Parameters.GetByInAndName("header", HeaderIfRange))
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.7"), 0o600))
+
+	file, err := OpenFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/pdf", file.ContentType)
+	assert.NotEmpty(t, file.ETag)
+	assert.False(t, file.ModTime.IsZero())
+	assert.NoError(t, file.Content.(io.Closer).Close())
+
+	_, err = OpenFile(filepath.Join(t.TempDir(), "missing.pdf"))
+	assert.EqualError(t, err, "file not found")
+
+	_, err = OpenFile(t.TempDir())
+	assert.EqualError(t, err, "file not found")
+}
@@ -0,0 +1,158 @@
+package lite
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Router is implemented by App and Group. Every route registration function
+// (Get, Post, ...) accepts a Router so routes can be declared on the App itself
+// or on any (nested) Group.
+type Router interface {
+	app() *App
+	options() routeOptions
+}
+
+var (
+	_ Router = &App{}
+	_ Router = &Group{}
+)
+
+// routeOptions are the settings a route inherits from the App and the Groups it is registered on.
+type routeOptions struct {
+	prefix         string
+	tags           []string
+	middleware     []*Middleware
+	security       openapi3.SecurityRequirements
+	errorResponses []errors.HTTPError
+	etag           ETagMode
+	requireIfMatch bool
+	idempotency    IdempotencyStore
+	timeout        time.Duration
+}
+
+// merge returns the options of o extended with the ones of child.
+func (o routeOptions) merge(child routeOptions) routeOptions {
+	return routeOptions{
+		prefix:         o.prefix + child.prefix,
+		tags:           append(append([]string{}, o.tags...), child.tags...),
+		middleware:     append(append([]*Middleware{}, o.middleware...), child.middleware...),
+		security:       append(append(openapi3.SecurityRequirements{}, o.security...), child.security...),
+		errorResponses: append(append([]errors.HTTPError{}, o.errorResponses...), child.errorResponses...),
+		etag:           cmp.Or(child.etag, o.etag),
+		requireIfMatch: o.requireIfMatch || child.requireIfMatch,
+		idempotency:    cmp.Or(child.idempotency, o.idempotency),
+		timeout:        cmp.Or(child.timeout, o.timeout),
+	}
+}
+
+// Group is a set of routes sharing a path prefix, OpenAPI tags, middleware,
+// security requirements and error responses.
+// Everything declared on a Group is inherited by its routes and child Groups.
+type Group struct {
+	parent Router
+	routeOptions
+}
+
+// Group creates a new Group whose routes are prefixed by prefix.
+func (s *App) Group(prefix string) *Group {
+	return newGroup(s, prefix)
+}
+
+// Group creates a child Group whose routes are prefixed by the parent prefix followed by prefix.
+func (g *Group) Group(prefix string) *Group {
+	return newGroup(g, prefix)
+}
+
+func newGroup(parent Router, prefix string) *Group {
+	return &Group{
+		parent: parent,
+		routeOptions: routeOptions{
+			prefix: prefix,
+		},
+	}
+}
+
+// AddTags adds OpenAPI tags to every route of the Group
+func (g *Group) AddTags(tags ...string) *Group {
+	g.tags = append(g.tags, tags...)
+
+	return g
+}
+
+// Use adds middleware executed before the handler of every route of the Group
+func (g *Group) Use(middleware ...fiber.Handler) *Group {
+	for _, handler := range middleware {
+		g.middleware = append(g.middleware, fiberMiddleware(handler))
+	}
+
+	return g
+}
+
+// UseMiddleware adds typed middleware executed before the handler of every route of the Group,
+// in the order of Use and UseMiddleware calls, and documented on every route
+func (g *Group) UseMiddleware(middleware ...*Middleware) *Group {
+	g.middleware = append(g.middleware, middleware...)
+
+	return g
+}
+
+// Security adds an OpenAPI security requirement to every route of the Group.
+// The security scheme must be declared with App.AddSecurityScheme.
+// The scopes are enforced as those of Route.Security.
+func (g *Group) Security(name string, scopes ...string) *Group {
+	sec := openapi3.NewSecurityRequirement()
+	sec[name] = append([]string{}, scopes...)
+
+	g.security = append(g.security, sec)
+
+	return g
+}
+
+// AddErrorResponses documents additional error responses for every route of the Group
+func (g *Group) AddErrorResponses(errs ...errors.HTTPError) *Group {
+	g.errorResponses = append(g.errorResponses, errs...)
+
+	return g
+}
+
+// ETag generates the entity tags of the responses of every route of the Group, as Route.ETag
+func (g *Group) ETag(mode ETagMode) *Group {
+	g.etag = mode
+
+	return g
+}
+
+// RequireIfMatch requires the If-Match header on every route of the Group, as Route.RequireIfMatch
+func (g *Group) RequireIfMatch() *Group {
+	g.requireIfMatch = true
+
+	return g
+}
+
+// Idempotent replays the responses of the requests of every route of the Group
+// with an Idempotency-Key header, as Route.Idempotent
+func (g *Group) Idempotent(store IdempotencyStore) *Group {
+	g.idempotency = store
+
+	return g
+}
+
+// Timeout cancels the context of the requests of every route of the Group after timeout, as Route.Timeout
+func (g *Group) Timeout(timeout time.Duration) *Group {
+	g.timeout = timeout
+
+	return g
+}
+
+func (g *Group) app() *App {
+	return g.parent.app()
+}
+
+func (g *Group) options() routeOptions {
+	return g.parent.options().merge(g.routeOptions)
+}
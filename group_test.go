@@ -0,0 +1,150 @@
+package lite
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type groupRequest struct {
+	ID uint64 `lite:"path=id"`
+}
+
+type groupResponse struct {
+	ID uint64 `json:"id"`
+}
+
+func TestGroup_Prefix(t *testing.T) {
+	app := New()
+
+	v1 := app.Group("/api").Group("/v1")
+
+	Get(v1, "/items/:id", func(c *ContextWithRequest[groupRequest]) (groupResponse, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return groupResponse{}, err
+		}
+
+		return groupResponse{ID: req.ID}, nil
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/items/42", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id":42}`, utils.UnsafeString(body))
+
+	assert.NotNil(t, app.OpenAPISpec.Paths.Find("/api/v1/items/{id}"))
+}
+
+func TestGroup_Tags(t *testing.T) {
+	app := New()
+	app.AddTags("app")
+
+	api := app.Group("/api").AddTags("api")
+	items := api.Group("/items").AddTags("items")
+
+	Get(items, "/", func(c *ContextNoRequest) (groupResponse, error) {
+		return groupResponse{}, nil
+	}).AddTags("route")
+
+	Get(api, "/health", func(c *ContextNoRequest) (groupResponse, error) {
+		return groupResponse{}, nil
+	})
+
+	assert.Equal(t, []string{"app", "api", "items", "route"}, app.OpenAPISpec.Paths.Find("/api/items/").Get.Tags)
+	assert.Equal(t, []string{"app", "api"}, app.OpenAPISpec.Paths.Find("/api/health").Get.Tags)
+}
+
+func TestGroup_Middleware(t *testing.T) {
+	app := New()
+
+	var calls []string
+
+	middleware := func(name string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			calls = append(calls, name)
+
+			return c.Next()
+		}
+	}
+
+	api := app.Group("/api").Use(middleware("api"))
+	v1 := api.Group("/v1").Use(middleware("v1"))
+
+	Get(v1, "/foo", func(c *ContextNoRequest) (groupResponse, error) {
+		calls = append(calls, "handler")
+
+		return groupResponse{}, nil
+	}, middleware("route"))
+
+	Get(app, "/bar", func(c *ContextNoRequest) (groupResponse, error) {
+		calls = append(calls, "bar")
+
+		return groupResponse{}, nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/foo", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []string{"api", "v1", "route", "handler"}, calls)
+
+	calls = nil
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/bar", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []string{"bar"}, calls)
+}
+
+func TestGroup_Security(t *testing.T) {
+	app := New()
+	app.AddSecurityScheme("bearerAuth", openapi3.NewJWTSecurityScheme())
+
+	admin := app.Group("/admin").Security("bearerAuth")
+
+	Get(admin, "/users", func(c *ContextNoRequest) (groupResponse, error) {
+		return groupResponse{}, nil
+	})
+
+	operation := app.OpenAPISpec.Paths.Find("/admin/users").Get
+
+	assert.NotNil(t, operation.Security)
+	assert.Equal(t, openapi3.SecurityRequirements{{"bearerAuth": []string{}}}, *operation.Security)
+	assert.Contains(t, app.OpenAPISpec.Components.SecuritySchemes, "bearerAuth")
+}
+
+func TestGroup_ErrorResponses(t *testing.T) {
+	app := New()
+
+	api := app.Group("/api").AddErrorResponses(errors.NewError(403, "Forbidden"))
+
+	Get(api.Group("/v1"), "/foo", func(c *ContextNoRequest) (groupResponse, error) {
+		return groupResponse{}, nil
+	})
+
+	operation := app.OpenAPISpec.Paths.Find("/api/v1/foo").Get
+
+	assert.NotNil(t, operation.Responses.Value("403"))
+	assert.NotNil(t, operation.Responses.Value("400"))
+}
+
+func TestGroup_OptionsNotSharedBetweenSiblings(t *testing.T) {
+	app := New()
+
+	api := app.Group("/api").AddTags("api")
+	first := api.Group("/first").AddTags("first")
+	second := api.Group("/second").AddTags("second")
+
+	assert.Equal(t, []string{"api", "first"}, first.options().tags)
+	assert.Equal(t, []string{"api", "second"}, second.options().tags)
+	assert.Equal(t, "/api/second", second.options().prefix)
+}
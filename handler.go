@@ -2,11 +2,16 @@ package lite
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"regexp"
+	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	liteErrors "github.com/go-lite/lite/errors"
 	"github.com/gofiber/fiber/v2"
 )
@@ -27,219 +32,289 @@ func newLiteContext[Request any, Contexted Context[Request]](ctx ContextNoReques
 }
 
 func fiberHandler[ResponseBody, Request any, Contexted Context[Request]](
+	app *App,
 	controller func(c Contexted) (ResponseBody, error),
 	path string,
+	route *registeredRoute,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		c.Context().SetContentType("application/json")
+		contentType, err := negotiateContentType(c, route)
+		if err != nil {
+			return writeError(c, app, err)
+		}
 
-		ctx := newLiteContext[Request, Contexted](ContextNoRequest{ctx: c, path: path})
+		c.Context().SetContentType(contentType)
 
-		c.Status(getStatusCode(c.Method()))
+		scope := scopeOf(c)
 
-		response, err := controller(ctx)
-		if err != nil {
-			// check if the error is a HTTPError and if so, return the error code
-			var httpError liteErrors.HTTPError
-			if errors.As(err, &httpError) {
-				c.Status(httpError.StatusCode())
+		if err := route.authenticate(c, app); err != nil {
+			return writeError(c, app, err)
+		}
+
+		if err := route.requireIfMatchHeader(c); err != nil {
+			return writeError(c, app, err)
+		}
+
+		return route.idempotent(c, app, func() error {
+			ctx := newLiteContext[Request, Contexted](ContextNoRequest{
+				ctx:            c,
+				app:            app,
+				path:           path,
+				binder:         route.binder,
+				requireIfMatch: route.requireIfMatch,
+			})
+
+			c.Status(route.info.StatusCode)
+
+			response, err := controller(ctx)
+			if err = timeoutError(scope.ctx, err); err != nil {
+				return writeError(c, app, err)
+			}
 
-				return c.JSON(httpError)
+			if !bodyAllowed(c.Response().StatusCode()) {
+				return nil
 			}
 
-			c.Status(http.StatusInternalServerError)
+			if body, ok := any(response).(streamedBody); ok && app.Serializer == nil {
+				// the stream releases the context once written, after the handler returned
+				scope.streamed = true
+				err = writeStreamedBody(c.Context(), body, scope.release)
+			} else if app.Serializer != nil {
+				err = app.Serializer(c.Context(), response)
+			} else {
+				err = serializeResponse(c.Context(), app.codecs, &response)
+			}
+
+			if err != nil {
+				return err
+			}
 
-			return c.JSON(liteErrors.DefaultErrorResponses[http.StatusInternalServerError].SetMessage(err.Error()))
+			route.writeETag(c)
+
+			return nil
+		})
+	}
+}
+
+// negotiateContentType returns the content type of the response of a route,
+// chosen from the Accept header of the request when the route produces several ones
+func negotiateContentType(c *fiber.Ctx, route *registeredRoute) (string, error) {
+	if len(route.produces) == 0 {
+		return route.contentType, nil
+	}
+
+	contentType := c.Accepts(route.produces...)
+	if contentType == "" {
+		return "", liteErrors.NewError(
+			http.StatusNotAcceptable,
+			"acceptable content types are "+strings.Join(route.produces, ", "),
+		)
+	}
+
+	return contentType, nil
+}
+
+// writeError writes the error returned by a controller, either as an HTTPError or as RFC 9457 problem details.
+// The error is serialized in XML when the client prefers it, in JSON otherwise.
+func writeError(c *fiber.Ctx, app *App, err error) error {
+	var (
+		httpError liteErrors.HTTPError
+		problem   liteErrors.ProblemDetails
+	)
+
+	// check if the error is a HTTPError and if so, return the error code
+	switch {
+	case errors.As(err, &problem):
+		httpError = problem.HTTPError()
+	case errors.As(err, &httpError):
+		problem = httpError.ProblemDetails()
+	default:
+		httpError = liteErrors.DefaultErrorResponses[http.StatusInternalServerError].SetMessage(err.Error())
+		problem = httpError.ProblemDetails()
+	}
+
+	c.Status(httpError.StatusCode())
+	c.Context().SetUserValue(errorKey, httpError)
+
+	accepted := c.Accepts(
+		fiber.MIMEApplicationJSON,
+		liteErrors.ProblemJSONContentType,
+		fiber.MIMEApplicationXML,
+		liteErrors.ProblemXMLContentType,
+	)
+	preferXML := accepted == fiber.MIMEApplicationXML || accepted == liteErrors.ProblemXMLContentType
+
+	if !app.problemDetails {
+		if preferXML {
+			return c.XML(httpError)
 		}
 
-		return serializeResponse(c.Context(), &response)
+		return c.JSON(httpError)
 	}
+
+	if problem.Instance == "" {
+		problem.Instance = c.Path()
+	}
+
+	var (
+		body       []byte
+		contentErr error
+	)
+
+	if preferXML {
+		c.Set(fiber.HeaderContentType, liteErrors.ProblemXMLContentType)
+		body, contentErr = xml.Marshal(problem)
+	} else {
+		c.Set(fiber.HeaderContentType, liteErrors.ProblemJSONContentType)
+		body, contentErr = json.Marshal(problem)
+	}
+
+	if contentErr != nil {
+		return contentErr
+	}
+
+	return c.Send(body)
 }
 
 func Get[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodGet,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodGet),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodGet, path, controller, middleware...)
 }
 
 func Post[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodPost,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodPost),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodPost, path, controller, middleware...)
 }
 
 func Put[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodPut,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodPut),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodPut, path, controller, middleware...)
 }
 
 func Delete[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodDelete,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodDelete),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodDelete, path, controller, middleware...)
 }
 
 func Patch[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodPatch,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodPatch),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodPatch, path, controller, middleware...)
 }
 
 func Head[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodHead,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodHead),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodHead, path, controller, middleware...)
 }
 
 func Connect[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodConnect,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodConnect),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodConnect, path, controller, middleware...)
 }
 
 func Trace[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	return registerRoute[ResponseBody, Request](
-		app,
-		Route[ResponseBody, Request]{
-			path:        path,
-			method:      http.MethodTrace,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodTrace),
-		},
-		fiberHandler[ResponseBody, Request](controller, path),
-		middleware...,
-	)
+	return newRoute[ResponseBody, Request](router, http.MethodTrace, path, controller, middleware...)
 }
 
 func Options[ResponseBody, Request any, Contexted Context[Request]](
-	app *App,
+	router Router,
 	path string,
 	controller func(Contexted) (ResponseBody, error),
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
+	return newRoute[ResponseBody, Request](router, http.MethodOptions, path, controller, middleware...)
+}
+
+func newRoute[ResponseBody, Request any, Contexted Context[Request]](
+	router Router,
+	method, path string,
+	controller func(Contexted) (ResponseBody, error),
+	middleware ...fiber.Handler,
+) Route[ResponseBody, Request] {
+	path = router.options().prefix + path
+	registered := &registeredRoute{scoped: true}
+
 	return registerRoute[ResponseBody, Request](
-		app,
+		router,
 		Route[ResponseBody, Request]{
+			app:         router.app(),
+			registered:  registered,
 			path:        path,
-			method:      http.MethodOptions,
-			contentType: "application/json",
-			statusCode:  getStatusCode(http.MethodOptions),
+			method:      method,
+			contentType: responseContentType[ResponseBody](),
+			statusCode:  getStatusCode(method, reflect.TypeOf((*ResponseBody)(nil)).Elem()),
 		},
-		fiberHandler[ResponseBody, Request](controller, path),
+		fiberHandler[ResponseBody, Request](router.app(), controller, path, registered),
 		middleware...,
 	)
 }
 
 func registerRoute[ResponseBody, Request any](
-	app *App,
+	router Router,
 	route Route[ResponseBody, Request],
 	controller fiber.Handler,
 	middleware ...fiber.Handler,
 ) Route[ResponseBody, Request] {
-	if len(middleware) > 0 {
-		app.Add(route.method,
-			route.path,
-			middleware...,
-		)
+	app := router.app()
+	options := router.options()
+
+	if route.registered == nil {
+		route.registered = &registeredRoute{}
 	}
 
+	handlers := make([]fiber.Handler, 0, len(options.middleware)+len(middleware)+2)
+	handlers = append(handlers, route.registered.instrumented(app))
+
+	if route.registered.scoped {
+		handlers = append(handlers, route.registered.withRequestContext())
+	}
+
+	for _, m := range options.middleware {
+		handler, err := m.handler(app, route.path)
+		if err != nil {
+			slog.ErrorContext(context.Background(), "failed to compile middleware", slog.Any("error", err))
+			panic(err)
+		}
+
+		handlers = append(handlers, handler)
+	}
+
+	handlers = append(handlers, middleware...)
+
+	app.Add(route.method, route.path, handlers...)
+
 	app.Add(
 		route.method,
 		route.path,
@@ -258,11 +333,91 @@ func registerRoute[ResponseBody, Request any](
 		panic(err)
 	}
 
+	err = applyRouteOptions(app, operation, route.statusCode, options)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to apply group options", slog.Any("error", err))
+		panic(err)
+	}
+
+	err = documentForbidden(app, operation)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
 	route.operation = operation
 
+	route.registered.info = RouteInfo{
+		Method:       route.method,
+		Path:         route.path,
+		StatusCode:   route.statusCode,
+		RequestType:  reflect.TypeOf((*Request)(nil)).Elem(),
+		ResponseType: reflect.TypeOf((*ResponseBody)(nil)).Elem(),
+		Streamed:     isStreamedBody[ResponseBody](),
+	}
+	route.registered.operation = operation
+
+	if requestType := route.registered.info.RequestType; requestType.Kind() == reflect.Struct {
+		route.registered.binder, err = newBinder(requestType, app.codecs)
+		if err != nil {
+			slog.ErrorContext(context.Background(), "failed to compile request binder", slog.Any("error", err))
+			panic(err)
+		}
+	}
+	route.registered.contentType = route.contentType
+	route.registered.etag = options.etag
+	route.registered.requireIfMatch = options.requireIfMatch
+	route.registered.idempotency = options.idempotency
+	route.registered.timeout = options.timeout
+
+	err = documentETag(app, operation, route.method, route.statusCode, route.registered)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	err = documentIdempotency(app, operation, route.method, route.registered)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	err = documentTimeout(app, operation, options.timeout)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	app.routes = append(app.routes, route.registered)
+
 	return route
 }
 
+// applyRouteOptions documents the tags, security requirements, error responses and middleware
+// inherited from the App and the Groups of a route.
+func applyRouteOptions(app *App, operation *openapi3.Operation, statusCode int, options routeOptions) error {
+	operation.Tags = append(operation.Tags, options.tags...)
+
+	addSecurityRequirements(operation, options.security)
+
+	for _, errResponse := range options.errorResponses {
+		response, err := app.createErrorResponse(errResponse)
+		if err != nil {
+			return err
+		}
+
+		operation.AddResponse(errResponse.StatusCode(), response)
+	}
+
+	for _, m := range options.middleware {
+		if err := m.document(app, operation, statusCode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // parseRoutePath parses the route path and returns the path and the query parameters.
 // Example : /item/:user/:id -> /item/{user}/{id}
 func parseRoutePath(route string) (string, []string) {
@@ -286,12 +441,17 @@ func parseRoutePath(route string) (string, []string) {
 	return modifiedRoute, params
 }
 
-// Get status code from the method
-func getStatusCode(method string) int {
+// getStatusCode returns the default status code of a route from its method and its response body.
+// DELETE routes respond 204 No Content, unless they return a body.
+func getStatusCode(method string, responseType reflect.Type) int {
 	switch method {
 	case http.MethodPost:
 		return http.StatusCreated
 	case http.MethodDelete:
+		if !isEmptyBody(responseType) {
+			return http.StatusOK
+		}
+
 		return http.StatusNoContent
 	case http.MethodGet, http.MethodPatch, http.MethodPut:
 		fallthrough
@@ -299,3 +459,13 @@ func getStatusCode(method string) int {
 		return http.StatusOK
 	}
 }
+
+// isEmptyBody reports whether a response body type has nothing to serialize, e.g. struct{}
+func isEmptyBody(responseType reflect.Type) bool {
+	return responseType.Kind() == reflect.Struct && responseType.NumField() == 0
+}
+
+// bodyAllowed reports whether a response with the given status code may have a body
+func bodyAllowed(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
@@ -145,7 +146,7 @@ func (suite *HandlerTestSuite) TestContextWithRequest_Path_Error() {
 	resp, err := app.Test(req)
 	assert.NoError(suite.T(), err)
 
-	assert.Equal(suite.T(), 500, resp.StatusCode, "Expected status code 400")
+	assert.Equal(suite.T(), 400, resp.StatusCode, "Expected status code 400")
 }
 
 type requestQuery struct {
@@ -250,6 +251,56 @@ func (suite *HandlerTestSuite) TestContextWithRequest_Header_Error() {
 	assert.Equal(suite.T(), 500, resp.StatusCode, "Expected status code 500")
 }
 
+type requestCookie struct {
+	ID      uint64       `lite:"cookie=id"`
+	Limit   *int         `lite:"cookie=limit"`
+	Session *http.Cookie `lite:"cookie=session"`
+}
+
+type responseCookie struct {
+	ID      uint64 `json:"id"`
+	Limit   *int   `json:"limit"`
+	Session string `json:"session"`
+}
+
+func (suite *HandlerTestSuite) TestContextWithRequest_Cookie() {
+	app := New()
+	Get(app, "/foo", func(c *ContextWithRequest[requestCookie]) (responseCookie, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return responseCookie{}, err
+		}
+
+		var session string
+		if req.Session != nil {
+			session = req.Session.Name + "=" + req.Session.Value
+		}
+
+		return responseCookie{
+			ID:      req.ID,
+			Limit:   req.Limit,
+			Session: session,
+		}, nil
+	})
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	req.AddCookie(&http.Cookie{Name: "id", Value: "123"})
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	resp, err := app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 200, resp.StatusCode, "Expected status code 200")
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(suite.T(), `{"id":123,"limit":null,"session":"session=abc"}`, utils.UnsafeString(body))
+
+	req = httptest.NewRequest("GET", "/foo", nil)
+	req.AddCookie(&http.Cookie{Name: "id", Value: "abc"})
+	resp, err = app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 400, resp.StatusCode, "Expected status code 400")
+}
+
 type reqBody struct {
 	ID float64 `json:"id" xml:"id"`
 }
@@ -460,6 +511,27 @@ func (suite *HandlerTestSuite) TestContextWithRequest_Delete() {
 	assert.Equal(suite.T(), 204, resp.StatusCode, "Expected status code 204")
 }
 
+func (suite *HandlerTestSuite) TestContextWithRequest_Delete_WithBody() {
+	app := New()
+	Delete(app, "/foo/:id", func(c *ContextWithRequest[requestDelete]) (responsePath, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return responsePath{}, err
+		}
+
+		return responsePath{ID: req.ID, Message: "deleted"}, nil
+	})
+
+	req := httptest.NewRequest("DELETE", "/foo/123", nil)
+	resp, err := app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 200, resp.StatusCode, "Expected status code 200")
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(suite.T(), `{"id":123,"message":"deleted"}`, utils.UnsafeString(body))
+	assert.NotNil(suite.T(), app.OpenAPISpec.Paths.Find("/foo/{id}").Delete.Responses.Value("200"))
+}
+
 type requestPatch struct {
 	ID uint64 `lite:"path=id"`
 }
@@ -704,29 +776,6 @@ func (suite *HandlerTestSuite) TestContextWithRequest_FullBody() {
 	assert.NoError(suite.T(), err)
 
 	expected := `components:
-    parameters:
-        cookie:
-            in: cookie
-            name: cookie
-            schema:
-                $ref: '#/components/schemas/cookie'
-        filter:
-            in: query
-            name: filter
-            schema:
-                $ref: '#/components/schemas/filter'
-        id:
-            in: path
-            name: id
-            required: true
-            schema:
-                $ref: '#/components/schemas/id'
-        is_admin:
-            in: path
-            name: is_admin
-            required: true
-            schema:
-                $ref: '#/components/schemas/is_admin'
     schemas:
         bodyRequest:
             properties:
@@ -746,40 +795,19 @@ func (suite *HandlerTestSuite) TestContextWithRequest_FullBody() {
                 - name
                 - file
             type: object
-        cookie:
+        httpGenericError:
             properties:
-                Domain:
-                    type: string
-                Expires:
-                    format: date-time
-                    type: string
-                HttpOnly:
-                    type: boolean
-                MaxAge:
-                    type: integer
-                Name:
-                    type: string
-                Path:
-                    type: string
-                Raw:
-                    type: string
-                RawExpires:
-                    type: string
-                SameSite:
-                    type: integer
-                Secure:
-                    type: boolean
-                Unparsed:
+                errors:
                     items:
-                        type: string
+                        properties:
+                            field:
+                                type: string
+                            in:
+                                type: string
+                            message:
+                                type: string
+                        type: object
                     type: array
-                Value:
-                    type: string
-            type: object
-        filter:
-            type: string
-        httpGenericError:
-            properties:
                 id:
                     type: string
                 message:
@@ -787,12 +815,6 @@ func (suite *HandlerTestSuite) TestContextWithRequest_FullBody() {
                 status:
                     type: integer
             type: object
-        id:
-            maximum: 1.8446744073709552e+19
-            minimum: 0
-            type: integer
-        is_admin:
-            type: string
         testResponse:
             properties:
                 first_name:
@@ -821,10 +843,26 @@ paths:
         post:
             operationId: POST/test/:id/:is_admin
             parameters:
-                - $ref: '#/components/parameters/id'
-                - $ref: '#/components/parameters/is_admin'
-                - $ref: '#/components/parameters/filter'
-                - $ref: '#/components/parameters/cookie'
+                - in: path
+                  name: id
+                  required: true
+                  schema:
+                    maximum: 1.8446744073709552e+19
+                    minimum: 0
+                    type: integer
+                - in: path
+                  name: is_admin
+                  required: true
+                  schema:
+                    type: string
+                - in: query
+                  name: filter
+                  schema:
+                    type: string
+                - in: cookie
+                  name: cookie
+                  schema:
+                    type: string
             requestBody:
                 content:
                     multipart/form-data:
@@ -900,3 +938,122 @@ paths:
 
 	assert.YAMLEqf(suite.T(), expected, string(spec), "openapi generated spec")
 }
+
+func (suite *HandlerTestSuite) TestContextWithRequest_Error_XML() {
+	app := New()
+	Get(app, "/foo", func(c *ContextNoRequest) (responsePath, error) {
+		return responsePath{}, errors.NewNotFoundError("foo not found")
+	})
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp, err := app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 404, resp.StatusCode)
+	assert.Equal(suite.T(), "application/xml", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), utils.UnsafeString(body), "<message>foo not found</message>")
+}
+
+func (suite *HandlerTestSuite) TestContextWithRequest_ProblemDetails() {
+	app := New().UseProblemDetails()
+	Get(app, "/foo/:id", func(c *ContextWithRequest[requestPath]) (responsePath, error) {
+		return responsePath{}, errors.NewNotFoundError("foo not found")
+	})
+	Get(app, "/bar", func(c *ContextNoRequest) (responsePath, error) {
+		return responsePath{}, assert.AnError
+	})
+	Get(app, "/baz", func(c *ContextNoRequest) (responsePath, error) {
+		problem := errors.NewProblemDetails(403, "out of credit").WithExtension("balance", 30)
+		problem.Type = "https://example.com/probs/out-of-credit"
+
+		return responsePath{}, problem
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/foo/123", nil))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 404, resp.StatusCode)
+	assert.Equal(suite.T(), "application/problem+json", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), utils.UnsafeString(body), `"type":"about:blank","title":"Not Found","status":404,`+
+		`"detail":"foo not found","instance":"/foo/123"`)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/bar", nil))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 500, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), utils.UnsafeString(body), `"detail":"`+assert.AnError.Error()+`"`)
+
+	req := httptest.NewRequest("GET", "/baz", nil)
+	req.Header.Set("Accept", "application/problem+xml")
+	resp, err = app.Test(req)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 403, resp.StatusCode)
+	assert.Equal(suite.T(), "application/problem+xml", resp.Header.Get("Content-Type"))
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(suite.T(), `<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/probs/out-of-credit</type>`+
+		`<title>Forbidden</title><status>403</status><detail>out of credit</detail><instance>/baz</instance>`+
+		`<balance>30</balance></problem>`, utils.UnsafeString(body))
+}
+
+func (suite *HandlerTestSuite) TestContextWithRequest_ProblemDetails_OpenAPI() {
+	app := New().UseProblemDetails()
+	Get(app, "/foo", func(c *ContextNoRequest) (responsePath, error) {
+		return responsePath{}, nil
+	})
+
+	response := app.OpenAPISpec.Paths.Find("/foo").Get.Responses.Value("404").Value
+
+	assert.Contains(suite.T(), response.Content, "application/problem+json")
+	assert.Contains(suite.T(), response.Content, "application/problem+xml")
+	assert.Equal(suite.T(), "#/components/schemas/problemDetails", response.Content["application/problem+json"].Schema.Ref)
+
+	schema := app.OpenAPISpec.Components.Schemas["problemDetails"].Value
+	assert.Contains(suite.T(), schema.Properties, "type")
+	assert.Contains(suite.T(), schema.Properties, "instance")
+	assert.True(suite.T(), *schema.AdditionalProperties.Has)
+	assert.NotContains(suite.T(), app.OpenAPISpec.Components.Schemas, "httpGenericError")
+}
+
+func (suite *HandlerTestSuite) TestContextWithRequest_Produces() {
+	app := New()
+	Get(app, "/foo/:id", func(c *ContextWithRequest[requestApplicationXML]) (responseApplicationXML, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return responseApplicationXML{}, err
+		}
+
+		return responseApplicationXML{ID: req.ID, Message: "Hello World"}, nil
+	}).Produces("application/json", "application/xml")
+
+	req := httptest.NewRequest("GET", "/foo/123", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp, err := app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 200, resp.StatusCode, "Expected status code 200")
+	assert.Equal(suite.T(), "application/xml", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(suite.T(), "<responseApplicationXML><id>123</id><message>Hello World</message></responseApplicationXML>", utils.UnsafeString(body))
+
+	req = httptest.NewRequest("GET", "/foo/123", nil)
+	resp, err = app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 200, resp.StatusCode, "Expected status code 200")
+	assert.Equal(suite.T(), "application/json", resp.Header.Get("Content-Type"))
+
+	req = httptest.NewRequest("GET", "/foo/123", nil)
+	req.Header.Set("Accept", "text/csv")
+	resp, err = app.Test(req)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), 406, resp.StatusCode, "Expected status code 406")
+
+	operation := app.OpenAPISpec.Paths.Find("/foo/{id}").Get
+	content := operation.Responses.Value("200").Value.Content
+	assert.NotNil(suite.T(), content["application/json"])
+	assert.NotNil(suite.T(), content["application/xml"])
+	assert.NotNil(suite.T(), operation.Responses.Value("406"))
+}
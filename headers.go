@@ -147,6 +147,8 @@ const (
 	HeaderAcceptSignature     = "Accept-Signature"
 	HeaderAltSvc              = "Alt-Svc"
 	HeaderDate                = "Date"
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
 	HeaderIndex               = "Index"
 	HeaderLargeAllocation     = "Large-Allocation"
 	HeaderLink                = "Link"
@@ -0,0 +1,417 @@
+package lite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxIdempotencyKeyLength is the maximum length of the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore stores the responses of the requests of idempotent routes, see Route.Idempotent.
+// Its methods are called concurrently, by every request with an Idempotency-Key header.
+type IdempotencyStore interface {
+	// Reserve records a request in flight for key, identified by the fingerprint of its method, URL and body.
+	// When the key is already known, it returns its record and false instead.
+	Reserve(ctx context.Context, key, fingerprint string) (IdempotencyRecord, bool, error)
+
+	// Complete stores the response of the request in flight for key
+	Complete(ctx context.Context, key string, record IdempotencyRecord) error
+
+	// Release forgets the request in flight for key, which failed and can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyRecord is the request stored for an idempotency key, and its response once completed
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`          // Hash of the method, URL and body of the request
+	Completed   bool                `json:"completed"`            // Whether the response is stored, false while the request is in flight
+	StatusCode  int                 `json:"statusCode,omitempty"` // Status code of the response
+	Header      map[string][]string `json:"header,omitempty"`     // Headers of the response
+	Body        []byte              `json:"body,omitempty"`       // Body of the response
+}
+
+// storedRecord is an IdempotencyRecord forgotten after its expiry
+type storedRecord struct {
+	IdempotencyRecord
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+func newStoredRecord(record IdempotencyRecord, ttl time.Duration) storedRecord {
+	stored := storedRecord{IdempotencyRecord: record}
+	if ttl > 0 {
+		stored.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	return stored
+}
+
+func (r storedRecord) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore keeping the records in memory,
+// for applications running a single process
+type MemoryIdempotencyStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	records   map[string]storedRecord
+	lastSweep time.Time
+}
+
+var _ IdempotencyStore = &MemoryIdempotencyStore{}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore forgetting the records after ttl, never when ttl is 0
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:       ttl,
+		records:   map[string]storedRecord{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key, fingerprint string) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if record, ok := s.records[key]; ok && !record.expired(now) {
+		return record.IdempotencyRecord, false, nil
+	}
+
+	s.records[key] = newStoredRecord(IdempotencyRecord{Fingerprint: fingerprint}, s.ttl)
+
+	return IdempotencyRecord{}, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = newStoredRecord(record, s.ttl)
+
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// sweep deletes the expired records, at most once per ttl
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+
+	for key, record := range s.records {
+		if record.expired(now) {
+			delete(s.records, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+// FileIdempotencyStore is an IdempotencyStore keeping each record in a JSON file of a directory,
+// which can be shared by the processes of an application running on the same host
+type FileIdempotencyStore struct {
+	dir string
+	ttl time.Duration
+}
+
+var _ IdempotencyStore = &FileIdempotencyStore{}
+
+// NewFileIdempotencyStore creates a FileIdempotencyStore in dir, created if needed,
+// forgetting the records after ttl, never when ttl is 0
+func NewFileIdempotencyStore(dir string, ttl time.Duration) (*FileIdempotencyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileIdempotencyStore{dir: dir, ttl: ttl}, nil
+}
+
+func (s *FileIdempotencyStore) Reserve(_ context.Context, key, fingerprint string) (IdempotencyRecord, bool, error) {
+	path := s.path(key)
+	reservation := newStoredRecord(IdempotencyRecord{Fingerprint: fingerprint}, s.ttl)
+
+	for {
+		// linking a complete file reserves the key atomically
+		err := s.write(reservation, func(tmp string) error { return os.Link(tmp, path) })
+		if err == nil {
+			return IdempotencyRecord{}, true, nil
+		}
+
+		if !stderrors.Is(err, fs.ErrExist) {
+			return IdempotencyRecord{}, false, err
+		}
+
+		record, err := s.read(path)
+		if stderrors.Is(err, fs.ErrNotExist) {
+			continue // released meanwhile
+		}
+
+		if err != nil {
+			return IdempotencyRecord{}, false, err
+		}
+
+		if !record.expired(time.Now()) {
+			return record.IdempotencyRecord, false, nil
+		}
+
+		if err := os.Remove(path); err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+			return IdempotencyRecord{}, false, err
+		}
+	}
+}
+
+func (s *FileIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord) error {
+	path := s.path(key)
+
+	return s.write(newStoredRecord(record, s.ttl), func(tmp string) error { return os.Rename(tmp, path) })
+}
+
+func (s *FileIdempotencyStore) Release(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path returns the path of the file of a key, named after its hash as keys are arbitrary strings
+func (s *FileIdempotencyStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// write writes record in a temporary file of the directory, then moves it in place with publish
+func (s *FileIdempotencyStore) write(record storedRecord, publish func(tmp string) error) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return publish(file.Name())
+}
+
+func (s *FileIdempotencyStore) read(path string) (storedRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return storedRecord{}, err
+	}
+
+	var record storedRecord
+
+	return record, json.Unmarshal(data, &record)
+}
+
+// idempotent handles a request of an idempotent route with handle, unless a request with the same Idempotency-Key
+// was already handled: its stored response is replayed, or an error is returned when it is still in flight (409)
+// or had another method, URL or body (422). Server errors and streamed responses are not stored.
+func (r *registeredRoute) idempotent(c *fiber.Ctx, app *App, handle func() error) error {
+	key := c.Get(HeaderIdempotencyKey)
+	if r.idempotency == nil || key == "" || !idempotentMethod(c.Method()) {
+		return handle()
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return writeError(c, app, errors.NewBadRequestError("the Idempotency-Key header is too long"))
+	}
+
+	store := r.idempotency
+	ctx := c.UserContext()
+	key = r.scopeIdempotencyKey(c, key)
+	fingerprint := idempotencyFingerprint(c)
+
+	record, reserved, err := store.Reserve(ctx, key, fingerprint)
+	if err != nil {
+		return writeError(c, app, err)
+	}
+
+	if !reserved {
+		return replay(c, app, record, fingerprint)
+	}
+
+	completed := false
+
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := store.Release(context.Background(), key); err != nil {
+			slog.ErrorContext(ctx, "failed to release idempotency key", slog.Any("error", err))
+		}
+	}()
+
+	if err := handle(); err != nil {
+		return err
+	}
+
+	response := c.Response()
+	if response.StatusCode() >= http.StatusInternalServerError || response.IsBodyStream() {
+		return nil
+	}
+
+	record = IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		StatusCode:  response.StatusCode(),
+		Header:      map[string][]string{},
+		Body:        append([]byte{}, response.Body()...),
+	}
+
+	response.Header.VisitAll(func(name, value []byte) {
+		switch header := string(name); header {
+		case HeaderContentLength, HeaderConnection, HeaderDate, HeaderServer, HeaderTransferEncoding:
+		default:
+			record.Header[header] = append(record.Header[header], string(value))
+		}
+	})
+
+	if err := store.Complete(ctx, key, record); err != nil {
+		slog.ErrorContext(ctx, "failed to store idempotent response", slog.Any("error", err))
+
+		return nil
+	}
+
+	completed = true
+
+	return nil
+}
+
+// replay writes the stored response of a request with the same Idempotency-Key
+func replay(c *fiber.Ctx, app *App, record IdempotencyRecord, fingerprint string) error {
+	if record.Fingerprint != fingerprint {
+		return writeError(c, app, errors.NewError(
+			http.StatusUnprocessableEntity,
+			"the Idempotency-Key was used by another request",
+		))
+	}
+
+	if !record.Completed {
+		c.Set(HeaderRetryAfter, "1")
+
+		return writeError(c, app, errors.NewConflictError("a request with the same Idempotency-Key is in progress"))
+	}
+
+	c.Status(record.StatusCode)
+
+	for name, values := range record.Header {
+		c.Response().Header.Del(name)
+
+		for _, value := range values {
+			c.Response().Header.Add(name, value)
+		}
+	}
+
+	c.Set(HeaderIdempotentReplayed, "true")
+	c.Response().SetBody(record.Body)
+
+	return nil
+}
+
+// scopeIdempotencyKey scopes an Idempotency-Key to the route and to the authenticated subject,
+// so that clients cannot replay the responses of each other
+func (r *registeredRoute) scopeIdempotencyKey(c *fiber.Ctx, key string) string {
+	scope := r.info.Method + " " + r.info.Path
+
+	if identity, ok := c.Context().UserValue(identityKey).(Identity); ok {
+		scope += " " + identity.Scheme + ":" + identity.Subject
+	}
+
+	return scope + " " + key
+}
+
+// idempotencyFingerprint hashes the method, URL and body of a request
+func idempotencyFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+
+	for _, part := range [][]byte{[]byte(c.Method()), []byte(c.OriginalURL()), c.Body()} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotentMethod reports whether the requests of a method can use an Idempotency-Key:
+// GET and HEAD requests are safe and never replayed
+func idempotentMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead
+}
+
+// documentIdempotency documents the Idempotency-Key header of an idempotent route,
+// and the errors of the requests reusing a key
+func documentIdempotency(s *App, operation *openapi3.Operation, method string, route *registeredRoute) error {
+	if route.idempotency == nil || !idempotentMethod(method) {
+		return nil
+	}
+
+	addHeaderParameter(operation, HeaderIdempotencyKey, false)
+
+	conflict, err := s.createErrorResponse(errors.NewConflictError())
+	if err != nil {
+		return err
+	}
+
+	conflict.Headers = headerRefs(HeaderRetryAfter)
+	operation.AddResponse(http.StatusConflict, conflict)
+
+	// the validation errors of the request may already be documented
+	if operation.Responses.Value(strconv.Itoa(http.StatusUnprocessableEntity)) != nil {
+		return nil
+	}
+
+	unprocessable, err := s.createErrorResponse(errors.NewError(http.StatusUnprocessableEntity))
+	if err != nil {
+		return err
+	}
+
+	operation.AddResponse(http.StatusUnprocessableEntity, unprocessable)
+
+	return nil
+}
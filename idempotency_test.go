@@ -0,0 +1,211 @@
+package lite
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payment struct {
+	ID     int64 `json:"id"`
+	Amount int64 `json:"amount"`
+}
+
+type createPaymentRequest struct {
+	Body payment `lite:"req=body"`
+}
+
+func TestIdempotent(t *testing.T) {
+	app := New()
+
+	var created atomic.Int64
+
+	payments := app.Group("/payments").Idempotent(NewMemoryIdempotencyStore(time.Hour))
+
+	Post(payments, "", func(c *ContextWithRequest[createPaymentRequest]) (payment, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return payment{}, err
+		}
+
+		if req.Body.Amount < 0 {
+			return payment{}, errors.NewInternalServerError()
+		}
+
+		c.Set("X-Payment", "created")
+
+		return payment{ID: created.Add(1), Amount: req.Body.Amount}, nil
+	})
+
+	post := func(key, body string) (int, string, map[string]string) {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, "application/json")
+
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		data, _ := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, string(data), map[string]string{
+			HeaderContentType:        resp.Header.Get(HeaderContentType),
+			HeaderIdempotentReplayed: resp.Header.Get(HeaderIdempotentReplayed),
+			"X-Payment":              resp.Header.Get("X-Payment"),
+		}
+	}
+
+	status, body, headers := post("a", `{"amount":10}`)
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"id":1,"amount":10}`, body)
+	assert.Empty(t, headers[HeaderIdempotentReplayed])
+
+	status, body, headers = post("a", `{"amount":10}`)
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"id":1,"amount":10}`, body)
+	assert.Equal(t, "true", headers[HeaderIdempotentReplayed])
+	assert.Equal(t, "created", headers["X-Payment"])
+	assert.Contains(t, headers[HeaderContentType], "application/json")
+
+	status, _, _ = post("a", `{"amount":20}`)
+	assert.Equal(t, 422, status)
+
+	status, body, _ = post("b", `{"amount":10}`)
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"id":2,"amount":10}`, body)
+
+	status, body, _ = post("", `{"amount":10}`)
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"id":3,"amount":10}`, body)
+
+	// server errors are not stored
+	status, _, _ = post("c", `{"amount":-1}`)
+	assert.Equal(t, 500, status)
+
+	status, _, _ = post("c", `{"amount":-1}`)
+	assert.Equal(t, 500, status)
+
+	status, _, _ = post(strings.Repeat("k", maxIdempotencyKeyLength+1), `{"amount":10}`)
+	assert.Equal(t, 400, status)
+
+	operation := app.OpenAPISpec.Paths.Find("/payments").Post
+	assert.NotNil(t, operation.Parameters.GetByInAndName("header", HeaderIdempotencyKey))
+	assert.NotNil(t, operation.Responses.Value("409"))
+	assert.NotNil(t, operation.Responses.Value("422"))
+}
+
+func TestIdempotent_InFlight(t *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	Post(app, "/payments", func(c *ContextWithRequest[createPaymentRequest]) (payment, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return payment{}, err
+		}
+
+		close(started)
+		<-release
+
+		return req.Body, nil
+	}).Idempotent(NewMemoryIdempotencyStore(0))
+
+	send := func() int {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader(`{"id":1,"amount":10}`))
+		req.Header.Set(HeaderContentType, "application/json")
+		req.Header.Set(HeaderIdempotencyKey, "a")
+
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+
+		return resp.StatusCode
+	}
+
+	first := make(chan int)
+
+	go func() {
+		first <- send()
+	}()
+
+	<-started
+	assert.Equal(t, 409, send())
+
+	close(release)
+	assert.Equal(t, 201, <-first)
+	assert.Equal(t, 201, send())
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	fileStore, err := NewFileIdempotencyStore(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	expiringFileStore, err := NewFileIdempotencyStore(t.TempDir(), time.Millisecond)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		store    IdempotencyStore
+		expiring IdempotencyStore
+	}{
+		{name: "memory", store: NewMemoryIdempotencyStore(0), expiring: NewMemoryIdempotencyStore(time.Millisecond)},
+		{name: "file", store: fileStore, expiring: expiringFileStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, reserved, err := tt.store.Reserve(ctx, "key", "fingerprint")
+			require.NoError(t, err)
+			assert.True(t, reserved)
+
+			record, reserved, err := tt.store.Reserve(ctx, "key", "other")
+			require.NoError(t, err)
+			assert.False(t, reserved)
+			assert.Equal(t, IdempotencyRecord{Fingerprint: "fingerprint"}, record)
+
+			completed := IdempotencyRecord{
+				Fingerprint: "fingerprint",
+				Completed:   true,
+				StatusCode:  201,
+				Header:      map[string][]string{"X-Test": {"a", "b"}},
+				Body:        []byte(`{"id":1}`),
+			}
+			require.NoError(t, tt.store.Complete(ctx, "key", completed))
+
+			record, reserved, err = tt.store.Reserve(ctx, "key", "fingerprint")
+			require.NoError(t, err)
+			assert.False(t, reserved)
+			assert.Equal(t, completed, record)
+
+			require.NoError(t, tt.store.Release(ctx, "key"))
+			require.NoError(t, tt.store.Release(ctx, "key"))
+
+			_, reserved, err = tt.store.Reserve(ctx, "key", "fingerprint")
+			require.NoError(t, err)
+			assert.True(t, reserved)
+
+			_, reserved, err = tt.expiring.Reserve(ctx, "key", "fingerprint")
+			require.NoError(t, err)
+			assert.True(t, reserved)
+
+			time.Sleep(5 * time.Millisecond)
+
+			_, reserved, err = tt.expiring.Reserve(ctx, "key", "fingerprint")
+			require.NoError(t, err)
+			assert.True(t, reserved)
+		})
+	}
+}
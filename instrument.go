@@ -0,0 +1,77 @@
+package lite
+
+import (
+	"sync"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Instrumentation observes the requests of the routes of an App, e.g. to trace or measure them, see App.Instrument
+type Instrumentation interface {
+	// Instrument returns the handler observing the requests of a route, created on its first request.
+	// The handler calls next to handle the request with the middleware and the handler of the route.
+	// The body of streamed responses (see RouteInfo.Streamed) is written once the handler returned,
+	// so the durations measured around next exclude it.
+	Instrument(route RouteInfo, next fiber.Handler) fiber.Handler
+}
+
+// Instrument observes the requests of every route with instrumentations, run before the middleware
+// of the routes, the first one observing the others. The routes registered before the call are observed too:
+// the instrumentations of a route are read on its first request, so Instrument must be called before
+// the App serves requests.
+func (s *App) Instrument(instrumentations ...Instrumentation) *App {
+	s.instrumentations = append(s.instrumentations, instrumentations...)
+
+	return s
+}
+
+// instrumented returns the handler running the instrumentations of the App before the other handlers of a route.
+// It is created on the first request of the route, once its operation id and the instrumentations are final.
+func (r *registeredRoute) instrumented(app *App) fiber.Handler {
+	var (
+		once    sync.Once
+		handler fiber.Handler
+	)
+
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			handler = func(c *fiber.Ctx) error {
+				return c.Next()
+			}
+
+			for i := len(app.instrumentations) - 1; i >= 0; i-- {
+				handler = app.instrumentations[i].Instrument(r.describe(), handler)
+			}
+		})
+
+		return handler(c)
+	}
+}
+
+// errorKey identifies the error written as the response of a request, see ErrorOf
+var errorKey = NewKey[errors.HTTPError]("lite.error")
+
+// ErrorOf returns the error written as the response of a request by a handler or a middleware,
+// false when there is none, e.g. for an Instrumentation recording it
+func ErrorOf(c *fiber.Ctx) (errors.HTTPError, bool) {
+	httpError, ok := c.Context().UserValue(errorKey).(errors.HTTPError)
+
+	return httpError, ok
+}
+
+// ResponseBodySize returns the size of the body of the response of a request, e.g. for an Instrumentation
+// recording it. The size of a streamed body is only known from its Content-Length, false when it has none.
+func ResponseBodySize(c *fiber.Ctx) (int, bool) {
+	response := c.Response()
+
+	if !response.IsBodyStream() {
+		return len(response.Body()), true
+	}
+
+	if size := response.Header.ContentLength(); size >= 0 {
+		return size, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,127 @@
+package lite
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingInstrumentation struct {
+	name     string
+	calls    *[]string
+	routes   []RouteInfo
+	errorIDs []string
+}
+
+func (i *recordingInstrumentation) Instrument(route RouteInfo, next fiber.Handler) fiber.Handler {
+	i.routes = append(i.routes, route)
+
+	return func(c *fiber.Ctx) error {
+		*i.calls = append(*i.calls, i.name)
+
+		err := next(c)
+
+		if httpError, ok := ErrorOf(c); ok {
+			i.errorIDs = append(i.errorIDs, httpError.ID)
+		}
+
+		return err
+	}
+}
+
+func TestApp_Instrument(t *testing.T) {
+	app := New()
+
+	var calls []string
+
+	outer := &recordingInstrumentation{name: "outer", calls: &calls}
+	inner := &recordingInstrumentation{name: "inner", calls: &calls}
+
+	Get(app, "/before", func(_ *ContextNoRequest) (string, error) {
+		return "", nil
+	})
+
+	app.Instrument(outer, inner)
+
+	type request struct {
+		ID string `lite:"path=id"`
+	}
+
+	Get(app, "/items/:id", func(c *ContextWithRequest[request]) (string, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return "", err
+		}
+
+		if req.ID == "0" {
+			return "", errors.NewNotFoundError()
+		}
+
+		return "item", nil
+	}).OperationID("getItem")
+
+	for _, path := range []string{"/before", "/items/1", "/items/0"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+
+	// the routes registered before Instrument are observed too
+	assert.Equal(t, []string{"outer", "inner", "outer", "inner", "outer", "inner"}, calls)
+
+	// created once per route, on its first request
+	require.Len(t, outer.routes, 2)
+	assert.Equal(t, "/before", outer.routes[0].Path)
+	assert.Equal(t, "getItem", outer.routes[1].OperationID)
+	assert.Equal(t, "/items/:id", outer.routes[1].Path)
+
+	require.Len(t, inner.errorIDs, 1)
+	assert.NotEmpty(t, inner.errorIDs[0])
+}
+
+func TestResponseBodySize(t *testing.T) {
+	app := New()
+
+	Get(app, "/sized", func(_ *ContextNoRequest) (Stream, error) {
+		return Stream{Reader: strings.NewReader("data"), Size: 4}, nil
+	})
+
+	Get(app, "/chunked", func(_ *ContextNoRequest) (Stream, error) {
+		return Stream{Reader: strings.NewReader("data")}, nil
+	})
+
+	Get(app, "/buffered", func(_ *ContextNoRequest) (string, error) {
+		return "data", nil
+	})
+
+	sizes := map[string]int{}
+
+	app.Instrument(instrumentationFunc(func(route RouteInfo, next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			err := next(c)
+
+			if size, ok := ResponseBodySize(c); ok {
+				sizes[route.Path] = size
+			}
+
+			return err
+		}
+	}))
+
+	for _, path := range []string{"/sized", "/chunked", "/buffered"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]int{"/sized": 4, "/buffered": 4}, sizes)
+}
+
+type instrumentationFunc func(route RouteInfo, next fiber.Handler) fiber.Handler
+
+func (f instrumentationFunc) Instrument(route RouteInfo, next fiber.Handler) fiber.Handler {
+	return f(route, next)
+}
@@ -0,0 +1,350 @@
+package lite
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 of HS256, RS256 and ES256
+	_ "crypto/sha512" // SHA-384 and SHA-512 of HS384, HS512, RS384, RS512, ES384 and ES512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWKS is a set of keys verifying the signature of JSON Web Tokens, see LoadJWKS.
+// Symmetric (oct), RSA and EC (P-256, P-384 and P-521) keys are supported.
+type JWKS struct {
+	keys []jwtKey
+}
+
+type jwtKey struct {
+	kid string
+	alg string
+	key any // []byte, *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JSON Web Key Set from a local file
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set, e.g. {"keys":[{"kty":"oct","kid":"1","k":"c2VjcmV0"}]}
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	jwks := &JWKS{}
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", jwk.Kid, err)
+		}
+
+		jwks.keys = append(jwks.keys, jwtKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+
+	return jwks, nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+// JWTAuthenticator verifies the JSON Web Tokens of the HTTP bearer scheme.
+// Tokens signed with HS256, HS384, HS512, RS256, RS384, RS512, ES256, ES384 or ES512 by a key of the JWKS are accepted,
+// unless they are expired (exp), not valid yet (nbf) or, when set, issued by another issuer (iss) or for another audience (aud).
+// The identity has the subject (sub), the scopes (scope or scp) and the claims of the token.
+type JWTAuthenticator struct {
+	Keys     *JWKS
+	Issuer   string        // Expected iss claim, any when empty
+	Audience string        // Expected aud claim, any when empty
+	Leeway   time.Duration // Clock skew allowed when checking exp and nbf
+}
+
+// NewJWTAuthenticator creates a JWTAuthenticator verifying tokens with keys
+func NewJWTAuthenticator(keys *JWKS) *JWTAuthenticator {
+	return &JWTAuthenticator{Keys: keys}
+}
+
+func (a *JWTAuthenticator) Authenticate(_ context.Context, credentials string) (Identity, error) {
+	token, ok := cutAuthScheme(credentials, "Bearer")
+	if !ok {
+		return Identity{}, fmt.Errorf("expected bearer token")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, fmt.Errorf("malformed token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("malformed token signature")
+	}
+
+	if err := a.verify(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return Identity{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, fmt.Errorf("malformed token claims")
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return Identity{Subject: subject, Scopes: tokenScopes(claims), Claims: claims}, nil
+}
+
+// tokenScopes returns the scopes granted to a token, by its scope claim (RFC 8693)
+// or its scp claim, a space separated string or an array of strings
+func tokenScopes(claims map[string]any) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []any:
+		scopes := make([]string, 0, len(scp))
+
+		for _, value := range scp {
+			if scope, ok := value.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+
+		return scopes
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dst)
+}
+
+// verify checks the signature of a token with the keys matching its algorithm and key ID
+func (a *JWTAuthenticator) verify(alg, kid, signed string, signature []byte) error {
+	hash, ok := jwtAlgorithms[alg]
+	if !ok {
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+
+	if a.Keys == nil {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	for _, key := range a.Keys.keys {
+		if (kid != "" && key.kid != "" && key.kid != kid) || (key.alg != "" && key.alg != alg) {
+			continue
+		}
+
+		if verifySignature(alg, hash, key.key, []byte(signed), sum, signature) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid token signature")
+}
+
+// jwtAlgorithms are the hashes of the supported signature algorithms, "none" is never accepted
+var jwtAlgorithms = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+func verifySignature(alg string, hash crypto.Hash, key any, signed, sum, signature []byte) bool {
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+	case "RS":
+		publicKey, ok := key.(*rsa.PublicKey)
+
+		return ok && rsa.VerifyPKCS1v15(publicKey, hash, sum, signature) == nil
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+
+		// the signature is the concatenation of r and s, each of the size of the curve
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		return ecdsa.Verify(publicKey, sum, r, s)
+	default:
+		return false
+	}
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := time.Now()
+
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(a.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-a.Leeway)) {
+		return fmt.Errorf("token not valid yet")
+	}
+
+	if a.Issuer != "" && claims["iss"] != a.Issuer {
+		return fmt.Errorf("invalid token issuer")
+	}
+
+	if a.Audience != "" && !hasAudience(claims["aud"], a.Audience) {
+		return fmt.Errorf("invalid token audience")
+	}
+
+	return nil
+}
+
+// hasAudience reports whether the aud claim, a string or an array of strings, contains audience
+func hasAudience(aud any, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
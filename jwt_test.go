@@ -0,0 +1,214 @@
+package lite
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jwtTestKeys struct {
+	secret []byte
+	rsa    *rsa.PrivateKey
+	ec     *ecdsa.PrivateKey
+}
+
+func newJWTTestKeys(t *testing.T) (jwtTestKeys, string) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	keys := jwtTestKeys{secret: []byte("secret"), rsa: rsaKey, ec: ecKey}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	ecPoint := func(n *big.Int) string {
+		return encode(n.FillBytes(make([]byte, 32)))
+	}
+
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"oct","kid":"hs","k":%q},
+		{"kty":"RSA","kid":"rs","alg":"RS256","n":%q,"e":%q},
+		{"kty":"EC","kid":"es","crv":"P-256","x":%q,"y":%q},
+		{"kty":"oct","kid":"enc","use":"enc","k":"ZW5j"}
+	]}`,
+		encode(keys.secret),
+		encode(rsaKey.N.Bytes()), encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+		ecPoint(ecKey.X), ecPoint(ecKey.Y),
+	)
+
+	return keys, jwks
+}
+
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	if key == nil {
+		return signed + "."
+	}
+
+	hash := jwtAlgorithms[alg]
+	digest := hash.New()
+	digest.Write([]byte(signed))
+
+	var signature []byte
+
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		require.NoError(t, err)
+
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	keys, jwks := newJWTTestKeys(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(jwks), 0o600))
+
+	set, err := LoadJWKS(path)
+	require.NoError(t, err)
+	assert.Len(t, set.keys, 3)
+
+	authenticator := NewJWTAuthenticator(set)
+	authenticator.Issuer = "lite"
+	authenticator.Audience = "api"
+
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{"sub": "john", "iss": "lite", "aud": []string{"api"}, "exp": time.Now().Add(time.Hour).Unix()}
+		for k, v := range extra {
+			c[k] = v
+		}
+
+		return c
+	}
+
+	valid := signJWT(t, "HS256", "hs", keys.secret, claims(nil))
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{name: "HS256", token: valid},
+		{name: "HS512", token: signJWT(t, "HS512", "", keys.secret, claims(nil))},
+		{name: "RS256", token: signJWT(t, "RS256", "rs", keys.rsa, claims(nil))},
+		{name: "ES256", token: signJWT(t, "ES256", "es", keys.ec, claims(nil))},
+		{name: "RS384 key restricted to RS256", token: signJWT(t, "RS384", "rs", keys.rsa, claims(nil)), err: "invalid token signature"},
+		{name: "wrong kid", token: signJWT(t, "HS256", "rs", keys.secret, claims(nil)), err: "invalid token signature"},
+		{name: "wrong secret", token: signJWT(t, "HS256", "hs", []byte("other"), claims(nil)), err: "invalid token signature"},
+		{name: "empty signature", token: valid[:len(valid)-43], err: "invalid token signature"},
+		{name: "alg none", token: signJWT(t, "none", "", nil, claims(nil)), err: `unsupported token algorithm "none"`},
+		{name: "expired", token: signJWT(t, "HS256", "hs", keys.secret, claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})), err: "token expired"},
+		{name: "not valid yet", token: signJWT(t, "HS256", "hs", keys.secret, claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})), err: "token not valid yet"},
+		{name: "wrong issuer", token: signJWT(t, "HS256", "hs", keys.secret, claims(map[string]any{"iss": "other"})), err: "invalid token issuer"},
+		{name: "wrong audience", token: signJWT(t, "HS256", "hs", keys.secret, claims(map[string]any{"aud": "other"})), err: "invalid token audience"},
+		{name: "malformed", token: "token", err: "malformed token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authenticator.Authenticate(context.Background(), "Bearer "+tt.token)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "john", identity.Subject)
+			assert.Equal(t, "lite", identity.Claims["iss"])
+		})
+	}
+
+	_, err = authenticator.Authenticate(context.Background(), "Basic "+valid)
+	assert.EqualError(t, err, "expected bearer token")
+}
+
+func TestJWTAuthenticator_Route(t *testing.T) {
+	keys, jwks := newJWTTestKeys(t)
+
+	set, err := ParseJWKS([]byte(jwks))
+	require.NoError(t, err)
+
+	app := New()
+	app.AddAuthenticator("bearerAuth", NewJWTAuthenticator(set))
+
+	Get(app, "/me", func(c *ContextWithRequest[authRequest]) (string, error) {
+		identity, _ := c.Identity()
+
+		return identity.Claims["role"].(string), nil
+	})
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+signJWT(t, "ES256", "es", keys.ec, map[string]any{"sub": "john", "role": "admin"}))
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "admin", string(body))
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Header.Get(HeaderWWWAuthenticate))
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, err := ParseJWKS([]byte(`{"keys":[{"kty":"OKP","kid":"ed"}]}`))
+	assert.EqualError(t, err, `invalid JWK "ed": unsupported key type "OKP"`)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"ec","crv":"P-192"}]}`))
+	assert.EqualError(t, err, `invalid JWK "ec": unsupported curve "P-192"`)
+
+	_, err = ParseJWKS([]byte(`keys`))
+	assert.Error(t, err)
+}
+
+func TestTokenScopes(t *testing.T) {
+	assert.Equal(t, []string{"read", "write"}, tokenScopes(map[string]any{"scope": "read write"}))
+	assert.Equal(t, []string{"read", "write"}, tokenScopes(map[string]any{"scp": []any{"read", "write"}}))
+	assert.Equal(t, []string{"read"}, tokenScopes(map[string]any{"scp": "read"}))
+	assert.Nil(t, tokenScopes(map[string]any{}))
+}
@@ -0,0 +1,294 @@
+package lite
+
+import (
+	"bytes"
+	stderrors "errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// DefaultLatencyBuckets are the default buckets of the request duration histogram, in seconds
+	DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// DefaultSizeBuckets are the default buckets of the response size histogram, in bytes
+	DefaultSizeBuckets = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000, 100_000_000}
+)
+
+// MetricsConfig configures the Prometheus metrics of an App, see App.UseMetrics
+type MetricsConfig struct {
+	Path           string    // Path of the metrics endpoint, /metrics by default
+	Namespace      string    // Prefix of the metric names, e.g. myapp for myapp_http_requests_total
+	LatencyBuckets []float64 // Buckets of the request duration histogram, DefaultLatencyBuckets by default
+	SizeBuckets    []float64 // Buckets of the response size histogram, DefaultSizeBuckets by default
+}
+
+// UseMetrics records Prometheus metrics of the requests of every route, see App.Instrument,
+// and serves them at the path of the config in the Prometheus text format.
+// The metrics are labelled by method, route template, OpenAPI operation id and status class (2xx, 4xx, ...):
+//
+//   - http_requests_total, the number of requests
+//   - http_request_duration_seconds, the histogram of the request durations, without the streamed bodies
+//   - http_response_size_bytes, the histogram of the response body sizes
+//   - http_requests_in_flight, the number of requests being handled, without status class
+//
+// The metrics endpoint is not documented in the OpenAPI spec.
+func (s *App) UseMetrics(config MetricsConfig) *App {
+	if config.Path == "" {
+		config.Path = "/metrics"
+	}
+
+	if config.Namespace != "" {
+		config.Namespace += "_"
+	}
+
+	if len(config.LatencyBuckets) == 0 {
+		config.LatencyBuckets = DefaultLatencyBuckets
+	}
+
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+
+	registry := &metricsRegistry{config: config}
+
+	s.Instrument(registry)
+
+	s.App.Get(config.Path, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, metricsContentType)
+
+		return c.Send(registry.expose())
+	})
+
+	return s
+}
+
+// metricsRegistry is the Instrumentation recording the metrics of the routes of an App
+type metricsRegistry struct {
+	config MetricsConfig
+
+	mu     sync.Mutex
+	routes []*routeMetrics // in the order of their first request
+}
+
+// routeMetrics are the metrics of a route, by status class
+type routeMetrics struct {
+	labels   string
+	inFlight atomic.Int64
+	classes  [5]*classMetrics // 1xx to 5xx
+}
+
+// classMetrics are the metrics of the requests of a route answered with a status class
+type classMetrics struct {
+	requests atomic.Uint64
+	duration *histogram
+	size     *histogram
+}
+
+func (m *metricsRegistry) Instrument(route RouteInfo, next fiber.Handler) fiber.Handler {
+	metrics := &routeMetrics{
+		labels: formatLabels("method", route.Method, "route", route.Path, "operation_id", route.OperationID),
+	}
+
+	for i := range metrics.classes {
+		metrics.classes[i] = &classMetrics{
+			duration: newHistogram(m.config.LatencyBuckets),
+			size:     newHistogram(m.config.SizeBuckets),
+		}
+	}
+
+	m.mu.Lock()
+	m.routes = append(m.routes, metrics)
+	m.mu.Unlock()
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		metrics.inFlight.Add(1)
+		defer metrics.inFlight.Add(-1)
+
+		err := next(c)
+
+		class := metrics.class(responseStatus(c, err))
+		class.requests.Add(1)
+		class.duration.observe(time.Since(start).Seconds())
+
+		if size, ok := ResponseBodySize(c); ok {
+			class.size.observe(float64(size))
+		}
+
+		return err
+	}
+}
+
+// class returns the metrics of a status class
+func (r *routeMetrics) class(status int) *classMetrics {
+	i := status/100 - 1
+	if i < 0 || i >= len(r.classes) {
+		i = http.StatusInternalServerError/100 - 1
+	}
+
+	return r.classes[i]
+}
+
+// responseStatus returns the status of the response of a request, once the error returned by its handlers,
+// if any, is written by the error handler of fiber
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberError *fiber.Error
+	if stderrors.As(err, &fiberError) {
+		return fiberError.Code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// expose writes the metrics in the Prometheus text format
+func (m *metricsRegistry) expose() []byte {
+	m.mu.Lock()
+	routes := append([]*routeMetrics{}, m.routes...)
+	m.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	name := m.config.Namespace + "http_requests_total"
+	writeMetricHeader(&buf, name, "counter", "Number of HTTP requests.")
+
+	for _, route := range routes {
+		route.eachClass(func(labels string, class *classMetrics) {
+			writeSample(&buf, name, labels, float64(class.requests.Load()))
+		})
+	}
+
+	name = m.config.Namespace + "http_request_duration_seconds"
+	writeMetricHeader(&buf, name, "histogram", "Duration of HTTP requests in seconds.")
+
+	for _, route := range routes {
+		route.eachClass(func(labels string, class *classMetrics) {
+			class.duration.write(&buf, name, labels)
+		})
+	}
+
+	name = m.config.Namespace + "http_response_size_bytes"
+	writeMetricHeader(&buf, name, "histogram", "Size of HTTP response bodies in bytes.")
+
+	for _, route := range routes {
+		route.eachClass(func(labels string, class *classMetrics) {
+			class.size.write(&buf, name, labels)
+		})
+	}
+
+	name = m.config.Namespace + "http_requests_in_flight"
+	writeMetricHeader(&buf, name, "gauge", "Number of HTTP requests being handled.")
+
+	for _, route := range routes {
+		writeSample(&buf, name, route.labels, float64(route.inFlight.Load()))
+	}
+
+	return buf.Bytes()
+}
+
+// eachClass calls f with the labels and the metrics of the status classes of the requests of a route
+func (r *routeMetrics) eachClass(f func(labels string, class *classMetrics)) {
+	for i, class := range r.classes {
+		if class.requests.Load() == 0 {
+			continue
+		}
+
+		f(r.labels+","+formatLabels("status_class", strconv.Itoa(i+1)+"xx"), class)
+	}
+}
+
+// histogram is a Prometheus histogram, whose observations are counted in the first bucket they fit in.
+// Its buckets, sum and count are observed and written under a lock, so that a scrape never sees them apart.
+type histogram struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, the last one counting the observations above every bucket
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	i := 0
+	for i < len(h.buckets) && value > h.buckets[i] {
+		i++
+	}
+
+	h.mu.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += value
+	h.mu.Unlock()
+}
+
+// write writes the cumulative buckets, the sum and the count of a histogram
+func (h *histogram) write(buf *bytes.Buffer, name, labels string) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	var cumulative uint64
+
+	for i, bound := range h.buckets {
+		cumulative += counts[i]
+		writeSample(buf, name+"_bucket", labels+","+formatLabels("le", formatFloat(bound)), float64(cumulative))
+	}
+
+	writeSample(buf, name+"_bucket", labels+","+formatLabels("le", "+Inf"), float64(count))
+	writeSample(buf, name+"_sum", labels, sum)
+	writeSample(buf, name+"_count", labels, float64(count))
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, kind, help string) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+func writeSample(buf *bytes.Buffer, name, labels string, value float64) {
+	buf.WriteString(name + "{" + labels + "} " + formatFloat(value) + "\n")
+}
+
+// labelEscaper escapes the label values of the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats pairs of label names and values
+func formatLabels(pairs ...string) string {
+	labels := make([]string, 0, len(pairs)/2)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+
+	return strings.Join(labels, ",")
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,187 @@
+package lite
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_UseMetrics(t *testing.T) {
+	app := New()
+	app.UseMetrics(MetricsConfig{})
+
+	type request struct {
+		ID string `lite:"path=id"`
+	}
+
+	Get(app, "/example/:id", func(c *ContextWithRequest[request]) (string, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return "", err
+		}
+
+		if req.ID == "0" {
+			return "", errors.NewNotFoundError()
+		}
+
+		return "example", nil
+	}).OperationID("getExample")
+
+	for _, path := range []string{"/example/1", "/example/2", "/example/0"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+	assert.Equal(t, metricsContentType, resp.Header.Get(HeaderContentType))
+
+	body, _ := io.ReadAll(resp.Body)
+	metrics := string(body)
+
+	labels := `method="GET",route="/example/:id",operation_id="getExample"`
+
+	for _, line := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{` + labels + `,status_class="2xx"} 2`,
+		`http_requests_total{` + labels + `,status_class="4xx"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{` + labels + `,status_class="2xx",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{` + labels + `,status_class="4xx"} 1`,
+		`http_response_size_bytes_bucket{` + labels + `,status_class="2xx",le="100"} 2`,
+		`http_response_size_bytes_sum{` + labels + `,status_class="2xx"} 14`,
+		"# TYPE http_requests_in_flight gauge",
+		`http_requests_in_flight{` + labels + `} 0`,
+	} {
+		assert.Contains(t, metrics, line+"\n")
+	}
+
+	assert.NotContains(t, metrics, "/example/1")
+	assert.NotContains(t, metrics, `status_class="5xx"`)
+	assert.Nil(t, app.OpenAPISpec.Paths.Find("/metrics"))
+}
+
+func TestApp_UseMetrics_Config(t *testing.T) {
+	app := New()
+	app.UseMetrics(MetricsConfig{
+		Path:           "/internal/metrics",
+		Namespace:      "shop",
+		LatencyBuckets: []float64{1},
+	})
+
+	Get(app, "/items", func(_ *ContextNoRequest) (string, error) {
+		return "", nil
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/items", nil))
+	require.NoError(t, err)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/internal/metrics", nil))
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	metrics := string(body)
+
+	assert.Contains(t, metrics, `shop_http_requests_total{method="GET",route="/items",operation_id="GET/items",status_class="2xx"} 1`)
+	assert.Equal(t, 2, strings.Count(metrics, "shop_http_request_duration_seconds_bucket{"))
+}
+
+func TestApp_UseMetrics_AfterRoutes(t *testing.T) {
+	app := New()
+
+	Get(app, "/items", func(_ *ContextNoRequest) (string, error) {
+		return "", nil
+	})
+
+	app.UseMetrics(MetricsConfig{})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/items", nil))
+	require.NoError(t, err)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Contains(t, string(body), `http_requests_total{method="GET",route="/items",operation_id="GET/items",status_class="2xx"} 1`)
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 2})
+
+	for _, value := range []float64{0.5, 1, 1.5, 3} {
+		h.observe(value)
+	}
+
+	var buf bytes.Buffer
+
+	h.write(&buf, "latency", `route="/"`)
+
+	assert.Equal(t, `latency_bucket{route="/",le="1"} 2
+latency_bucket{route="/",le="2"} 3
+latency_bucket{route="/",le="+Inf"} 4
+latency_sum{route="/"} 6
+latency_count{route="/"} 4
+`, buf.String())
+}
+
+// a scrape during observations sees the buckets, the sum and the count of the same observations
+func TestHistogram_Concurrent(t *testing.T) {
+	h := newHistogram([]float64{1})
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 10000 {
+				h.observe(1)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		var buf bytes.Buffer
+
+		h.write(&buf, "latency", `route="/"`)
+
+		samples := map[string]string{}
+
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			name, value, _ := strings.Cut(line, " ")
+			samples[name] = value
+		}
+
+		count := samples[`latency_count{route="/"}`]
+		require.Equal(t, count, samples[`latency_bucket{route="/",le="1"}`])
+		require.Equal(t, count, samples[`latency_bucket{route="/",le="+Inf"}`])
+		require.Equal(t, count, samples[`latency_sum{route="/"}`])
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	assert.Equal(t, `route="/a\"b\\c\n"`, formatLabels("route", "/a\"b\\c\n"))
+}
@@ -0,0 +1,192 @@
+package lite
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Middleware runs before the handler of the routes of an App or a Group (see App.UseMiddleware and Group.UseMiddleware).
+// Unlike a plain fiber.Handler, it decodes its own typed request, attaches typed values to the request
+// for the handlers (see Key) and documents itself in the OpenAPI spec of the routes.
+type Middleware struct {
+	// handler returns the fiber.Handler of the middleware for a route
+	handler func(app *App, path string) (fiber.Handler, error)
+
+	// request is the type of the request of the middleware, nil for plain fiber.Handlers
+	request reflect.Type
+
+	security        openapi3.SecurityRequirements
+	errorResponses  []errors.HTTPError
+	responseHeaders []string
+}
+
+// NewMiddleware creates a Middleware whose handler receives the request declared by Request,
+// e.g. the headers it reads. The parameters of Request are decoded and validated by c.Requests(),
+// and documented on every route of the Middleware. Use struct{} when the middleware has no request.
+// The handler calls c.Next() to run the next handlers of the route,
+// the error it returns is written as the response of the route.
+//
+//	type authRequest struct {
+//		Token string `lite:"header=Authorization,isauth"`
+//	}
+//
+//	var userKey = lite.NewKey[User]("user")
+//
+//	auth := lite.NewMiddleware(func(c *lite.ContextWithRequest[authRequest]) error {
+//		req, err := c.Requests()
+//		if err != nil {
+//			return err
+//		}
+//
+//		user, err := authenticate(req.Token)
+//		if err != nil {
+//			return errors.NewUnauthorizedError()
+//		}
+//
+//		userKey.Set(c, user)
+//
+//		return c.Next()
+//	}).AddErrorResponses(errors.NewUnauthorizedError())
+func NewMiddleware[Request any](handler func(c *ContextWithRequest[Request]) error) *Middleware {
+	requestType := reflect.TypeOf((*Request)(nil)).Elem()
+
+	return &Middleware{
+		request: requestType,
+		handler: func(app *App, path string) (fiber.Handler, error) {
+			var (
+				b   *binder
+				err error
+			)
+
+			if requestType.Kind() == reflect.Struct {
+				b, err = newBinder(requestType, app.codecs)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return func(c *fiber.Ctx) error {
+				if b != nil {
+					if err := authenticate(c, app, b.security); err != nil {
+						return writeError(c, app, err)
+					}
+				}
+
+				ctx := &ContextWithRequest[Request]{
+					ContextNoRequest: ContextNoRequest{ctx: c, app: app, path: path, binder: b},
+				}
+
+				if err := handler(ctx); err != nil {
+					return writeError(c, app, err)
+				}
+
+				return nil
+			}, nil
+		},
+	}
+}
+
+// fiberMiddleware wraps a plain fiber.Handler in a Middleware which documents nothing
+func fiberMiddleware(handler fiber.Handler) *Middleware {
+	return &Middleware{
+		handler: func(*App, string) (fiber.Handler, error) {
+			return handler, nil
+		},
+	}
+}
+
+// Security documents a security requirement on every route of the Middleware.
+// The security scheme must be declared with App.AddSecurityScheme.
+func (m *Middleware) Security(name string, scopes ...string) *Middleware {
+	sec := openapi3.NewSecurityRequirement()
+	sec[name] = append([]string{}, scopes...)
+
+	m.security = append(m.security, sec)
+
+	return m
+}
+
+// AddErrorResponses documents the error responses the Middleware can return on every route
+func (m *Middleware) AddErrorResponses(errs ...errors.HTTPError) *Middleware {
+	m.errorResponses = append(m.errorResponses, errs...)
+
+	return m
+}
+
+// AddResponseHeaders documents the headers the Middleware sets on the successful response of every route
+func (m *Middleware) AddResponseHeaders(headers ...string) *Middleware {
+	m.responseHeaders = append(m.responseHeaders, headers...)
+
+	return m
+}
+
+// document documents the request, security requirements, error responses and response headers
+// of the Middleware on the operation of a route
+func (m *Middleware) document(app *App, operation *openapi3.Operation, statusCode int) error {
+	if m.request != nil && m.request.Kind() == reflect.Struct {
+		if err := register(app, operation, reflect.New(m.request).Elem()); err != nil {
+			return err
+		}
+
+		if hasValidationRules(m.request) {
+			response, err := app.createErrorResponse(errors.NewValidationError())
+			if err != nil {
+				return err
+			}
+
+			operation.AddResponse(http.StatusUnprocessableEntity, response)
+		}
+	}
+
+	addSecurityRequirements(operation, m.security)
+
+	for _, errResponse := range m.errorResponses {
+		response, err := app.createErrorResponse(errResponse)
+		if err != nil {
+			return err
+		}
+
+		operation.AddResponse(errResponse.StatusCode(), response)
+	}
+
+	if response := operation.Responses.Value(strconv.Itoa(statusCode)); response != nil && len(m.responseHeaders) > 0 {
+		if response.Value.Headers == nil {
+			response.Value.Headers = make(openapi3.Headers, len(m.responseHeaders))
+		}
+
+		for _, header := range m.responseHeaders {
+			response.Value.Headers[header] = newHeaderRef()
+		}
+	}
+
+	return nil
+}
+
+// Key identifies a typed value attached to a request, e.g. by a Middleware authenticating its user.
+// Keys are compared by name and type.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns the Key of the values of type T with the given name
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Set attaches value to the request of c
+func (k Key[T]) Set(c interface{ RequestContext() *fasthttp.RequestCtx }, value T) {
+	c.RequestContext().SetUserValue(k, value)
+}
+
+// Get returns the value attached to the request of c, false when there is none
+func (k Key[T]) Get(c interface{ RequestContext() *fasthttp.RequestCtx }) (T, bool) {
+	value, ok := c.RequestContext().UserValue(k).(T)
+
+	return value, ok
+}
@@ -0,0 +1,147 @@
+package lite
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type principal struct {
+	Name string
+}
+
+var principalKey = NewKey[principal]("principal")
+
+type authRequest struct {
+	Token     string `lite:"header=Authorization,isauth,scheme=bearer,name=bearerAuth"`
+	RequestID string `lite:"header=X-Request-ID"`
+}
+
+func newAuthMiddleware() *Middleware {
+	return NewMiddleware(func(c *ContextWithRequest[authRequest]) error {
+		req, err := c.Requests()
+		if err != nil {
+			return err
+		}
+
+		name, ok := strings.CutPrefix(req.Token, "Bearer ")
+		if !ok {
+			return errors.NewUnauthorizedError("missing token")
+		}
+
+		principalKey.Set(c, principal{Name: name})
+
+		return c.Next()
+	}).
+		AddErrorResponses(errors.NewUnauthorizedError()).
+		AddResponseHeaders("X-RateLimit-Remaining")
+}
+
+func TestMiddleware(t *testing.T) {
+	app := New()
+
+	app.AddAuthenticator("bearerAuth", APIKeyAuthenticator(func(_ context.Context, token string) (Identity, error) {
+		return Identity{Subject: token}, nil
+	}))
+
+	api := app.Group("/api").UseMiddleware(newAuthMiddleware())
+
+	Get(api, "/me", func(c *ContextNoRequest) (string, error) {
+		p, ok := principalKey.Get(c)
+		if !ok {
+			return "", errors.NewInternalServerError("no principal")
+		}
+
+		return p.Name, nil
+	})
+
+	req := httptest.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer john")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "john", string(body))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/me", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 401, resp.StatusCode)
+
+	operation := app.OpenAPISpec.Paths.Find("/api/me").Get
+	assert.Equal(t, "X-Request-ID", operation.Parameters[0].Value.Name)
+	assert.Contains(t, (*operation.Security)[0], "bearerAuth")
+	assert.NotNil(t, app.OpenAPISpec.Components.SecuritySchemes["bearerAuth"])
+	assert.NotNil(t, operation.Responses.Value("401"))
+	assert.NotNil(t, operation.Responses.Value("200").Value.Headers["X-RateLimit-Remaining"])
+}
+
+func TestMiddleware_Order(t *testing.T) {
+	app := New()
+
+	var calls []string
+
+	step := func(name string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			calls = append(calls, name)
+
+			return c.Next()
+		}
+	}
+
+	typed := NewMiddleware(func(c *ContextWithRequest[struct{}]) error {
+		calls = append(calls, "typed")
+
+		return c.Next()
+	})
+
+	app.UseMiddleware(typed)
+
+	api := app.Group("/api").Use(step("use")).UseMiddleware(typed)
+
+	Get(api, "/foo", func(c *ContextNoRequest) (string, error) {
+		calls = append(calls, "handler")
+
+		return "", nil
+	}, step("route"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/foo", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []string{"typed", "use", "typed", "route", "handler"}, calls)
+}
+
+func TestKey(t *testing.T) {
+	app := New()
+
+	var (
+		other = NewKey[string]("principal")
+		got   principal
+		ok    bool
+	)
+
+	Get(app, "/foo", func(c *ContextNoRequest) (string, error) {
+		principalKey.Set(c, principal{Name: "john"})
+
+		_, found := other.Get(c)
+		assert.False(t, found)
+
+		got, ok = principalKey.Get(c)
+
+		return "", nil
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/foo", nil))
+	assert.NoError(t, err)
+
+	assert.True(t, ok)
+	assert.Equal(t, principal{Name: "john"}, got)
+}
@@ -2,18 +2,24 @@ package lite
 
 import (
 	"fmt"
+	"maps"
 	"mime/multipart"
+	"net/http"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/go-lite/lite/errors"
 )
 
-var generator = openapi3gen.NewGenerator(
-	openapi3gen.UseAllExportedFields(),
-)
-
-var generatorNewSchemaRefForValue = generator.NewSchemaRefForValue
+// generatorNewSchemaRefForValue generates the schema of a value with a new generator each time:
+// the generated schemas are updated in place, so they must not be cached and shared between Apps.
+var generatorNewSchemaRefForValue = func(value any, schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+	return openapi3gen.NewSchemaRefForValue(value, schemas, openapi3gen.UseAllExportedFields())
+}
 
 func registerOpenAPIOperation[ResponseBody, RequestBody any](
 	s *App,
@@ -36,37 +42,24 @@ func registerOpenAPIOperation[ResponseBody, RequestBody any](
 
 	routePath, _ := parseRoutePath(path)
 
-	tag := tagFromType(*new(ResponseBody))
+	response := openapi3.NewResponse().WithDescription("OK")
 
-	responseSchema, ok := s.OpenAPISpec.Components.Schemas[tag]
-	if !ok {
-		responseSchema, err = generatorNewSchemaRefForValue(new(ResponseBody), s.OpenAPISpec.Components.Schemas)
+	if bodyAllowed(statusCode) {
+		content, err := newBodyContent[ResponseBody](s, resContentType)
 		if err != nil {
 			return operation, err
 		}
 
-		fieldGenericType := reflect.TypeOf(*new(ResponseBody))
-
-		getRequiredValue(resContentType, fieldGenericType, responseSchema.Value)
-
-		s.OpenAPISpec.Components.Schemas[tag] = responseSchema
-	}
-
-	response := openapi3.NewResponse().WithDescription("OK")
-
-	if responseSchema != nil {
-		content := openapi3.NewContentWithSchemaRef(
-			openapi3.NewSchemaRef(fmt.Sprintf(
-				"#/components/schemas/%s",
-				tag,
-			), &openapi3.Schema{}),
-			[]string{resContentType},
-		)
 		response.WithContent(content)
 	}
 
 	operation.AddResponse(statusCode, response)
 
+	// Document the responses to the range and conditional requests of files
+	if _, ok := any(*new(ResponseBody)).(File); ok && statusCode == http.StatusOK {
+		documentFile(operation, response)
+	}
+
 	// Add error responses
 	responses, err := s.createDefaultErrorResponses()
 	if err != nil {
@@ -77,6 +70,16 @@ func registerOpenAPIOperation[ResponseBody, RequestBody any](
 		operation.AddResponse(code, resp)
 	}
 
+	// Document the validation errors of the request
+	if hasValidationRules(valGen.Type()) {
+		response, err := s.createErrorResponse(errors.NewValidationError())
+		if err != nil {
+			return nil, err
+		}
+
+		operation.AddResponse(http.StatusUnprocessableEntity, response)
+	}
+
 	// Remove default response
 	operation.Responses.Delete("default")
 
@@ -85,27 +88,76 @@ func registerOpenAPIOperation[ResponseBody, RequestBody any](
 	return operation, nil
 }
 
-func getRequiredValue(contentType string, fieldType reflect.Type, schema *openapi3.Schema) bool {
+// newBodyContent returns the content of a response body, documented with the schema of its items when it is streamed
+func newBodyContent[ResponseBody any](s *App, contentType string) (openapi3.Content, error) {
+	body, ok := any(*new(ResponseBody)).(streamedBody)
+	if !ok {
+		return newResponseContent(s, []string{contentType}, new(ResponseBody), reflect.TypeOf(*new(ResponseBody)))
+	}
+
+	schema, err := body.streamSchema(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return openapi3.NewContentWithSchemaRef(schema, []string{contentType}), nil
+}
+
+// newResponseContent documents the schema of a response body in the components of the spec
+// and returns the content of the response referencing it in each of the given content types.
+func newResponseContent(s *App, contentTypes []string, body any, bodyType reflect.Type) (openapi3.Content, error) {
+	tag := tagFromType(body)
+
+	responseSchema, ok := s.OpenAPISpec.Components.Schemas[tag]
+	if !ok {
+		var err error
+
+		responseSchema, err = generatorNewSchemaRefForValue(body, s.OpenAPISpec.Components.Schemas)
+		if err != nil {
+			return nil, err
+		}
+
+		getRequiredValue(getStructTag(s.codecs, contentTypes[0]), bodyType, responseSchema.Value)
+
+		s.OpenAPISpec.Components.Schemas[tag] = responseSchema
+	}
+
+	if responseSchema == nil {
+		return nil, nil
+	}
+
+	return openapi3.NewContentWithSchemaRef(
+		openapi3.NewSchemaRef(fmt.Sprintf(
+			"#/components/schemas/%s",
+			tag,
+		), &openapi3.Schema{}),
+		contentTypes,
+	), nil
+}
+
+// getRequiredValue lists the required properties of a schema, named by the given struct tag.
+// It reports whether a value of fieldType is required.
+func getRequiredValue(structTag string, fieldType reflect.Type, schema *openapi3.Schema) bool {
 	switch fieldType.Kind() {
 	case reflect.Struct:
 		for k := 0; k < fieldType.NumField(); k++ {
 			field := fieldType.Field(k)
 			fieldName := field.Name
 
-			if field.Tag.Get(getStructTag(contentType)) != "" {
-				if contentType != "application/json" {
-					jsonFieldName := field.Tag.Get(getStructTag("application/json"))
+			if structTagName(field, structTag) != "" {
+				if structTag != "json" {
+					jsonFieldName := structTagName(field, "json")
 					if jsonFieldName != "" {
 						fieldName = jsonFieldName
 					}
 
-					updateKey(schema.Properties, fieldName, field.Tag.Get(getStructTag(contentType)))
+					updateKey(schema.Properties, fieldName, structTagName(field, structTag))
 				}
 
-				fieldName = field.Tag.Get(getStructTag(contentType))
+				fieldName = structTagName(field, structTag)
 			}
 
-			ok := getRequiredValue(contentType, field.Type, schema.Properties[fieldName].Value)
+			ok := getRequiredValue(structTag, field.Type, schema.Properties[fieldName].Value)
 			if ok {
 				schema.Required = append(schema.Required, fieldName)
 			}
@@ -113,13 +165,13 @@ func getRequiredValue(contentType string, fieldType reflect.Type, schema *openap
 
 		return true
 	case reflect.Array, reflect.Slice:
-		if fieldType.Elem().Kind() == reflect.Uint8 {
+		if fieldType.Elem().Kind() == reflect.Uint8 || schema.Items == nil {
 			return true
 		}
 
-		return getRequiredValue(contentType, fieldType.Elem(), schema.Items.Value)
+		return getRequiredValue(structTag, fieldType.Elem(), schema.Items.Value)
 	case reflect.Map:
-		getRequiredValue(contentType, fieldType.Elem(), schema.AdditionalProperties.Schema.Value)
+		getRequiredValue(structTag, fieldType.Elem(), schema.AdditionalProperties.Schema.Value)
 		return false
 	case reflect.Interface:
 		return false
@@ -188,7 +240,8 @@ func register(s *App, operation *openapi3.Operation, dstVal reflect.Value) error
 			continue
 		}
 
-		isRequired := fieldType.Kind() != reflect.Ptr
+		// nil pointers and slices are optional parameters
+		isRequired := fieldType.Kind() != reflect.Ptr && fieldType.Kind() != reflect.Slice
 
 		if tag == "" {
 			tag = field.Name
@@ -196,73 +249,105 @@ func register(s *App, operation *openapi3.Operation, dstVal reflect.Value) error
 
 		tagMap := parseTag(tag)
 
+		rules, err := validationRules(field)
+		if err != nil {
+			return err
+		}
+
+		if hasRule(rules, ruleRequired) {
+			isRequired = true
+		}
+
 		var parameter *openapi3.Parameter
-		var scheme, tpe, name string
+
+		if _, isAuth := tagMap["isauth"]; isAuth {
+			if in, key := paramLocation(tagMap); in == "header" || in == "query" || in == "cookie" {
+				name, securityScheme := securitySchemeOf(in, key, tagMap)
+
+				if _, ok := s.OpenAPISpec.Components.SecuritySchemes[name]; !ok &&
+					(securityScheme.Type == "oauth2" || securityScheme.Type == "openIdConnect") {
+					return fmt.Errorf("security scheme %s must be declared with App.AddSecurityScheme", name)
+				}
+
+				setSecurityScheme(s, operation, name, securityScheme, strings.Fields(tagMap["scopes"])...)
+
+				continue
+			}
+		}
 
 		if pathKey, ok := tagMap["path"]; ok {
 			parameter = openapi3.NewPathParameter(pathKey)
 
-			err := setParamSchema(s, operation, pathKey, parameter, isRequired, fieldType)
+			if err := setParamStyle(parameter, fieldType, tagMap); err != nil {
+				return err
+			}
+
+			err := setParamSchema(s, operation, parameter, isRequired, fieldType, rules)
 			if err != nil {
 				return err
 			}
 		} else if queryKey, ok := tagMap["query"]; ok {
 			parameter = openapi3.NewQueryParameter(queryKey)
-			err := setParamSchema(s, operation, queryKey, parameter, isRequired, fieldType)
+
+			if err := setParamStyle(parameter, fieldType, tagMap); err != nil {
+				return err
+			}
+
+			err := setParamSchema(s, operation, parameter, isRequired, fieldType, rules)
 			if err != nil {
 				return err
 			}
 		} else if headerKey, ok := tagMap["header"]; ok {
 			parameter = openapi3.NewHeaderParameter(headerKey)
 			parameter.Required = isRequired
-			var isAuth bool
 
-			if _, isAuth = tagMap["isauth"]; isAuth {
-				tpe = "http"
-				name = "Authorization"
-				scheme = "bearer"
+			if err := setParamStyle(parameter, fieldType, tagMap); err != nil {
+				return err
 			}
 
-			if valueScheme, ok := tagMap["scheme"]; ok {
-				scheme = valueScheme
+			err := setParamSchema(s, operation, parameter, isRequired, fieldType, rules)
+			if err != nil {
+				return err
 			}
+		} else if cookieKey, ok := tagMap["cookie"]; ok {
+			parameter = openapi3.NewCookieParameter(cookieKey)
 
-			if valueName, ok := tagMap["name"]; ok {
-				name = valueName
+			if err := setParamStyle(parameter, fieldType, tagMap); err != nil {
+				return err
 			}
 
-			if isAuth {
-				setSecurityScheme(s, operation, name, tpe, scheme)
-			} else {
-				err := setHeaderScheme(s, operation, tag, parameter)
-				if err != nil {
-					return err
-				}
+			if indirectType(fieldType) == cookieType {
+				// http.Cookie fields hold the value of the cookie, not its attributes
+				fieldType = reflect.TypeOf("")
 			}
-		} else if cookieKey, ok := tagMap["cookie"]; ok {
-			parameter = openapi3.NewCookieParameter(cookieKey)
-			err := setParamSchema(s, operation, cookieKey, parameter, isRequired, fieldType)
+
+			err := setParamSchema(s, operation, parameter, isRequired, fieldType, rules)
 			if err != nil {
 				return err
 			}
 		} else if reqKey, ok := tagMap["req"]; ok {
 			if reqKey == "body" {
-				contentType := "application/json"
-
 				if len(tagMap) > 2 {
 					panic("invalid tag")
 				}
 
-				if len(tagMap) == 2 {
-					for key := range tagMap {
-						if key != "req" {
-							contentType = key
+				if patch, ok := fieldVal.Interface().(patchBody); ok {
+					schema, err := patch.patchSchema(s)
+					if err != nil {
+						return err
+					}
 
-							break
-						}
+					operation.RequestBody = &openapi3.RequestBodyRef{
+						Value: openapi3.NewRequestBody().WithContent(
+							openapi3.NewContentWithSchemaRef(schema, []string{patch.patchContentType()}),
+						),
 					}
+
+					continue
 				}
 
+				contentType := bodyContentType(tagMap)
+
 				_, ok := s.OpenAPISpec.Components.Schemas[fieldVal.Type().Name()]
 				if !ok {
 					var err error
@@ -280,7 +365,14 @@ func register(s *App, operation *openapi3.Operation, dstVal reflect.Value) error
 						return err
 					}
 
-					getRequiredValue(contentType, fieldType, bodySchema.Value)
+					structTag := getStructTag(s.codecs, contentType)
+
+					getRequiredValue(structTag, fieldType, bodySchema.Value)
+
+					err = applyBodyValidationSchema(structTag, fieldType, bodySchema.Value)
+					if err != nil {
+						return err
+					}
 
 					s.OpenAPISpec.Components.Schemas[fieldVal.Type().Name()] = bodySchema
 				}
@@ -328,88 +420,143 @@ func updateFileHeaderFieldType(fieldType reflect.Type) reflect.Type {
 	return reflect.StructOf(fields)
 }
 
-func setHeaderScheme(s *App, operation *openapi3.Operation, tag string, parameter *openapi3.Parameter) error {
-	_, ok := s.OpenAPISpec.Components.Schemas[tag]
-	if !ok {
-		var err error
-
-		headerSchema, err := generatorNewSchemaRefForValue(new(string), s.OpenAPISpec.Components.Schemas)
-		if err != nil {
-			return err
-		}
-
-		s.OpenAPISpec.Components.Schemas[tag] = headerSchema
+// setParamStyle documents the style and explode options of an array parameter
+func setParamStyle(parameter *openapi3.Parameter, fieldType reflect.Type, tagMap map[string]string) error {
+	if !isArrayParam(fieldType) {
+		return nil
 	}
 
-	s.OpenAPISpec.Components.Headers[tag] = &openapi3.HeaderRef{
-		Value: &openapi3.Header{
-			Parameter: *parameter,
-		},
+	style, err := newParamStyle(parameter.In, tagMap)
+	if err != nil {
+		return err
 	}
 
-	operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{
-		Ref: "#/components/parameters/" + tag,
-	})
+	parameter.Style = style.style
+	parameter.Explode = &style.explode
 
 	return nil
 }
 
-func setSecurityScheme(s *App, operation *openapi3.Operation, name string, tpe string, scheme string) {
-	sec := openapi3.NewSecurityRequirement()
-	sec[name] = []string{}
+func setSecurityScheme(s *App, operation *openapi3.Operation, name string, securityScheme *openapi3.SecurityScheme, scopes ...string) {
+	addSecurityRequirement(operation, name, scopes)
 
-	securityScheme := openapi3.NewSecurityScheme()
-	securityScheme.Type = tpe
-	securityScheme.Scheme = scheme
+	// a security scheme declared with App.AddSecurityScheme, e.g. with its OAuth2 flows, is kept
+	if _, ok := s.OpenAPISpec.Components.SecuritySchemes[name]; !ok {
+		s.OpenAPISpec.Components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{
+			Value: securityScheme,
+		}
+	}
+}
 
+// addSecurityRequirement requires the security scheme name with scopes on an operation.
+// The scopes are added to an existing requirement of this scheme alone, so that all of them are required.
+func addSecurityRequirement(operation *openapi3.Operation, name string, scopes []string) {
 	if operation.Security == nil {
 		operation.Security = openapi3.NewSecurityRequirements()
 	}
 
-	operation.Security.With(
-		sec,
-	)
+	for _, requirement := range *operation.Security {
+		if existing, ok := requirement[name]; ok && len(requirement) == 1 {
+			for _, scope := range scopes {
+				if !slices.Contains(existing, scope) {
+					existing = append(existing, scope)
+				}
+			}
+
+			requirement[name] = existing
+
+			return
+		}
+	}
+
+	sec := openapi3.NewSecurityRequirement()
+	sec[name] = append([]string{}, scopes...)
+
+	operation.Security.With(sec)
+}
+
+// addSecurityRequirements adds the security requirements of a Group or a Middleware to an operation
+func addSecurityRequirements(operation *openapi3.Operation, requirements openapi3.SecurityRequirements) {
+	for _, requirement := range requirements {
+		if len(requirement) == 1 {
+			for name, scopes := range requirement {
+				addSecurityRequirement(operation, name, scopes)
+			}
+
+			continue
+		}
+
+		if operation.Security == nil {
+			operation.Security = openapi3.NewSecurityRequirements()
+		}
+
+		operation.Security.With(maps.Clone(requirement))
+	}
+}
+
+// documentForbidden documents the 403 Forbidden response of an operation requiring scopes
+func documentForbidden(s *App, operation *openapi3.Operation) error {
+	if operation.Security == nil || operation.Responses.Value(strconv.Itoa(http.StatusForbidden)) != nil {
+		return nil
+	}
 
-	securitySchemes := make(map[string]*openapi3.SecuritySchemeRef)
-	securitySchemes[name] = &openapi3.SecuritySchemeRef{
-		Value: securityScheme,
+	for _, requirement := range *operation.Security {
+		for _, scopes := range requirement {
+			if len(scopes) == 0 {
+				continue
+			}
+
+			response, err := s.createErrorResponse(errors.NewForbiddenError())
+			if err != nil {
+				return err
+			}
+
+			operation.AddResponse(http.StatusForbidden, response)
+
+			return nil
+		}
 	}
 
-	s.OpenAPISpec.Components.SecuritySchemes[name] = securitySchemes[name]
+	return nil
 }
 
+// setParamSchema documents a parameter inline in the operation, so that the rules, style and
+// required flag of a parameter do not leak to the parameters of the same name of other routes
 func setParamSchema(
 	s *App,
 	operation *openapi3.Operation,
-	tag string,
 	parameter *openapi3.Parameter,
 	isRequired bool,
 	fieldType reflect.Type,
+	rules []validationRule,
 ) error {
-	ref := fmt.Sprintf("#/components/schemas/%s", tag)
-
-	parameter.Schema = openapi3.NewSchemaRef(ref, &openapi3.Schema{})
 	parameter.Required = isRequired
 
-	_, ok := s.OpenAPISpec.Components.Schemas[tag]
-	if !ok {
-		var err error
-		newInstance := reflect.New(fieldType).Elem().Interface()
+	schemaType := indirectType(fieldType)
+	if schemaType.Kind() == reflect.Array {
+		// arrays are documented as slices of their length
+		schemaType = reflect.SliceOf(schemaType.Elem())
+	}
 
-		paramSchema, err := generatorNewSchemaRefForValue(newInstance, s.OpenAPISpec.Components.Schemas)
-		if err != nil {
-			return err
-		}
+	newInstance := reflect.New(schemaType).Elem().Interface()
 
-		s.OpenAPISpec.Components.Schemas[tag] = paramSchema
+	paramSchema, err := generatorNewSchemaRefForValue(newInstance, s.OpenAPISpec.Components.Schemas)
+	if err != nil {
+		return err
 	}
 
-	s.OpenAPISpec.Components.Parameters[tag] = &openapi3.ParameterRef{
-		Value: parameter,
+	if arrayType := indirectType(fieldType); arrayType.Kind() == reflect.Array && paramSchema.Value != nil {
+		length := uint64(arrayType.Len())
+
+		paramSchema.Value.MaxItems = &length
 	}
 
+	applyValidationSchema(paramSchema.Value, fieldType, rules)
+
+	parameter.Schema = paramSchema
+
 	operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{
-		Ref: "#/components/parameters/" + tag,
+		Value: parameter,
 	})
 
 	return nil
@@ -440,26 +587,19 @@ func dive(t reflect.Type, maxDepth int) string {
 	}
 }
 
-// get struct tag from content type
-func getStructTag(contentType string) string {
-	switch contentType {
-	case "application/json":
-		return "json"
-	case "application/xml", "text/xml":
-		return "xml"
-	case "application/x-www-form-urlencoded", "multipart/form-data":
-		return "form"
-	case "text/plain":
-		return "txt"
-	case "application/octet-stream":
-		return "binary"
-	case "application/pdf":
-		return "pdf"
-	case "image/png":
-		return "png"
-	case "image/jpeg":
-		return "jpeg"
-	default:
-		return "json"
+// structTagName returns the field name declared by a struct tag, without its options
+func structTagName(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+
+	return name
+}
+
+// getStructTag returns the struct tag naming the fields of a body in a content type,
+// from the codec registered for it, json by default
+func getStructTag(codecs codecRegistry, contentType string) string {
+	if codec, ok := codecs.lookup(contentType); ok {
+		return codec.StructTag()
 	}
+
+	return "json"
 }
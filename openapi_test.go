@@ -91,7 +91,7 @@ type testStruct struct {
 }
 
 func TestGetRequiredValue_Struct(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf(testStruct{})
 	schema := &openapi3.Schema{
 		Properties: map[string]*openapi3.SchemaRef{
@@ -101,7 +101,7 @@ func TestGetRequiredValue_Struct(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if !ok {
 		t.Errorf("expected true, got false")
 	}
@@ -111,7 +111,7 @@ func TestGetRequiredValue_Struct(t *testing.T) {
 }
 
 func TestGetRequiredValue_StructWithXML(t *testing.T) {
-	contentType := "application/xml"
+	structTag := "xml"
 	fieldType := reflect.TypeOf(testStruct{})
 	schema := &openapi3.Schema{
 		Properties: map[string]*openapi3.SchemaRef{
@@ -121,7 +121,7 @@ func TestGetRequiredValue_StructWithXML(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if !ok {
 		t.Errorf("expected true, got false")
 	}
@@ -131,7 +131,7 @@ func TestGetRequiredValue_StructWithXML(t *testing.T) {
 }
 
 func TestGetRequiredValue_StructWithForm(t *testing.T) {
-	contentType := "application/x-www-form-urlencoded"
+	structTag := "form"
 	fieldType := reflect.TypeOf(testStruct{})
 	schema := &openapi3.Schema{
 		Properties: map[string]*openapi3.SchemaRef{
@@ -141,7 +141,7 @@ func TestGetRequiredValue_StructWithForm(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if !ok {
 		t.Errorf("expected true, got false")
 	}
@@ -151,7 +151,7 @@ func TestGetRequiredValue_StructWithForm(t *testing.T) {
 }
 
 func TestGetRequiredValue_Slice(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf([]int{})
 	schema := &openapi3.Schema{
 		Items: &openapi3.SchemaRef{
@@ -159,14 +159,14 @@ func TestGetRequiredValue_Slice(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if !ok {
 		t.Errorf("expected true, got false")
 	}
 }
 
 func TestGetRequiredValue_SliceOfStructWithPointer(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf([]*testStruct{})
 	schema := &openapi3.Schema{
 		Items: &openapi3.SchemaRef{
@@ -174,14 +174,14 @@ func TestGetRequiredValue_SliceOfStructWithPointer(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if ok {
 		t.Errorf("expected true, got false")
 	}
 }
 
 func TestGetRequiredValue_SliceOfUint8(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf([]byte{})
 	schema := &openapi3.Schema{
 		Items: &openapi3.SchemaRef{
@@ -189,47 +189,47 @@ func TestGetRequiredValue_SliceOfUint8(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if !ok {
 		t.Errorf("expected true, got false")
 	}
 }
 
 func TestGetRequiredValue_Interface(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf((*interface{})(nil)).Elem()
 	schema := &openapi3.Schema{}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if ok {
 		t.Errorf("expected false, got true")
 	}
 }
 
 func TestGetRequiredValue_Pointer(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf((*int)(nil))
 	schema := &openapi3.Schema{}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if ok {
 		t.Errorf("expected false, got true")
 	}
 }
 
 func TestGetRequiredValue_PointerOfStruct(t *testing.T) {
-	contentType := "application/xml"
+	structTag := "xml"
 	fieldType := reflect.TypeOf((*testStruct)(nil))
 	schema := &openapi3.Schema{}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if ok {
 		t.Errorf("expected false, got true")
 	}
 }
 
 func TestGetRequiredValue_Map(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf(map[string]int{})
 	schema := &openapi3.Schema{
 		AdditionalProperties: openapi3.AdditionalProperties{
@@ -237,7 +237,7 @@ func TestGetRequiredValue_Map(t *testing.T) {
 		},
 	}
 
-	ok := getRequiredValue(contentType, fieldType, schema)
+	ok := getRequiredValue(structTag, fieldType, schema)
 	if ok {
 		t.Errorf("expected true, got false")
 	}
@@ -251,15 +251,15 @@ func TestGetRequiredValue_ChanPanic(t *testing.T) {
 		}
 	}()
 
-	contentType := "application/json"
+	structTag := "json"
 	fieldType := reflect.TypeOf(make(chan int))
 	schema := &openapi3.Schema{}
 
-	getRequiredValue(contentType, fieldType, schema)
+	getRequiredValue(structTag, fieldType, schema)
 }
 
 func TestGetRequiredValue_BasicTypes(t *testing.T) {
-	contentType := "application/json"
+	structTag := "json"
 	basicTypes := []reflect.Type{
 		reflect.TypeOf(true),
 		reflect.TypeOf(1),
@@ -270,7 +270,7 @@ func TestGetRequiredValue_BasicTypes(t *testing.T) {
 	for _, fieldType := range basicTypes {
 		schema := &openapi3.Schema{}
 
-		ok := getRequiredValue(contentType, fieldType, schema)
+		ok := getRequiredValue(structTag, fieldType, schema)
 		if !ok {
 			t.Errorf("expected true, got false for type %v", fieldType)
 		}
@@ -523,6 +523,29 @@ func TestRegisterCookie(t *testing.T) {
 	}
 }
 
+type testReqCookieAuth struct {
+	Session string `lite:"cookie=session,isauth,name=cookieAuth"`
+}
+
+func TestRegisterCookieAuth(t *testing.T) {
+	app := New()
+	operation := openapi3.NewOperation()
+	dstVal := reflect.ValueOf(testReqCookieAuth{})
+
+	err := register(app, operation, dstVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, operation.Parameters)
+	assert.Equal(t, openapi3.SecurityRequirement{"cookieAuth": []string{}}, (*operation.Security)[0])
+
+	scheme := app.OpenAPISpec.Components.SecuritySchemes["cookieAuth"].Value
+	assert.Equal(t, "apiKey", scheme.Type)
+	assert.Equal(t, "cookie", scheme.In)
+	assert.Equal(t, "session", scheme.Name)
+}
+
 func TestRegisterCookieGeneratorNewSchemaRefForValueError(t *testing.T) {
 	testErr := testReq5{}
 
@@ -547,77 +570,77 @@ func TestRegisterCookieGeneratorNewSchemaRefForValueError(t *testing.T) {
 func TestGetStructTag(t *testing.T) {
 	contentType := "text/plain"
 
-	tag := getStructTag(contentType)
+	tag := getStructTag(defaultCodecs(), contentType)
 	if tag != "txt" {
 		t.Errorf("expected txt, got %s", tag)
 	}
 
 	contentType = "application/json"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "json" {
 		t.Errorf("expected json, got %s", tag)
 	}
 
 	contentType = "application/xml"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "xml" {
 		t.Errorf("expected xml, got %s", tag)
 	}
 
 	contentType = "application/x-www-form-urlencoded"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "form" {
 		t.Errorf("expected form, got %s", tag)
 	}
 
 	contentType = "multipart/form-data"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "form" {
 		t.Errorf("expected form, got %s", tag)
 	}
 
 	contentType = "text/plain"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "txt" {
 		t.Errorf("expected txt, got %s", tag)
 	}
 
 	contentType = "application/octet-stream"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "binary" {
 		t.Errorf("expected binary, got %s", tag)
 	}
 
 	contentType = "application/pdf"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "pdf" {
 		t.Errorf("expected pdf, got %s", tag)
 	}
 
 	contentType = "image/png"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "png" {
 		t.Errorf("expected png, got %s", tag)
 	}
 
 	contentType = "image/jpeg"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "jpeg" {
 		t.Errorf("expected jpeg, got %s", tag)
 	}
 
 	contentType = "application/fake+json"
 
-	tag = getStructTag(contentType)
+	tag = getStructTag(defaultCodecs(), contentType)
 	if tag != "json" {
 		t.Errorf("expected json, got %s", tag)
 	}
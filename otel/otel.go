@@ -0,0 +1,251 @@
+// Package otel traces and measures the requests of the routes of a lite.App with OpenTelemetry,
+// following the HTTP semantic conventions:
+//
+//	instrumentation, err := otel.New(otel.Config{})
+//	if err != nil {
+//		return err
+//	}
+//
+//	app := lite.New()
+//	app.Instrument(instrumentation)
+package otel
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-lite/lite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	otelglobal "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the name of the instrumentation scope of the spans and metrics
+const ScopeName = "github.com/go-lite/lite/otel"
+
+const (
+	// ErrorIDKey is the attribute of the id of the lite HTTPError written as the response of a request
+	ErrorIDKey = attribute.Key("lite.error.id")
+
+	// ErrorStatusKey is the attribute of the status of the lite HTTPError written as the response of a request
+	ErrorStatusKey = attribute.Key("lite.error.status")
+)
+
+// durationBuckets are the bucket boundaries of the request duration advised by the semantic conventions, in seconds
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Config configures an Instrumentation
+type Config struct {
+	TracerProvider trace.TracerProvider          // Provider of the tracer of the server spans, the global one when nil
+	MeterProvider  metric.MeterProvider          // Provider of the meter of the histograms, the global one when nil
+	Propagator     propagation.TextMapPropagator // Propagator extracting the trace context, W3C trace context and baggage when nil
+}
+
+// Instrumentation is a lite.Instrumentation starting a server span for every request of a route,
+// named by the operation id of the route, and recording the duration and the body sizes of the requests
+type Instrumentation struct {
+	tracer       trace.Tracer
+	propagator   propagation.TextMapPropagator
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+var _ lite.Instrumentation = &Instrumentation{}
+
+// New creates an Instrumentation
+func New(config Config) (*Instrumentation, error) {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otelglobal.GetTracerProvider()
+	}
+
+	if config.MeterProvider == nil {
+		config.MeterProvider = otelglobal.GetMeterProvider()
+	}
+
+	if config.Propagator == nil {
+		config.Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+
+	meter := config.MeterProvider.Meter(ScopeName, metric.WithSchemaURL(semconv.SchemaURL))
+
+	duration, err := meter.Float64Histogram(
+		semconv.HTTPServerRequestDurationName,
+		metric.WithUnit(semconv.HTTPServerRequestDurationUnit),
+		metric.WithDescription(semconv.HTTPServerRequestDurationDescription),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestSize, err := meter.Int64Histogram(
+		semconv.HTTPServerRequestBodySizeName,
+		metric.WithUnit(semconv.HTTPServerRequestBodySizeUnit),
+		metric.WithDescription(semconv.HTTPServerRequestBodySizeDescription),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	responseSize, err := meter.Int64Histogram(
+		semconv.HTTPServerResponseBodySizeName,
+		metric.WithUnit(semconv.HTTPServerResponseBodySizeUnit),
+		metric.WithDescription(semconv.HTTPServerResponseBodySizeDescription),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:       config.TracerProvider.Tracer(ScopeName, trace.WithSchemaURL(semconv.SchemaURL)),
+		propagator:   config.Propagator,
+		duration:     duration,
+		requestSize:  requestSize,
+		responseSize: responseSize,
+	}, nil
+}
+
+// Instrument returns the handler tracing and measuring the requests of a route.
+// The context of the requests, see lite.Context.Context, carries their server span.
+// The spans and the durations of streamed responses end before their body is written.
+func (i *Instrumentation) Instrument(route lite.RouteInfo, next fiber.Handler) fiber.Handler {
+	spanName := route.OperationID
+	if spanName == "" {
+		spanName = route.Method + " " + route.Path
+	}
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		ctx := i.propagator.Extract(c.UserContext(), headerCarrier{header: &c.Request().Header})
+
+		ctx, span := i.tracer.Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(requestAttributes(c, route)...),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		err := next(c)
+
+		status := c.Response().StatusCode()
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(c.Method()),
+			semconv.URLScheme(c.Protocol()),
+			semconv.HTTPRoute(route.Path),
+			semconv.HTTPResponseStatusCode(status),
+		}
+
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			attributes = append(attributes, semconv.ErrorTypeKey.String(errorType(err)))
+		case status >= http.StatusInternalServerError:
+			span.SetStatus(codes.Error, http.StatusText(status))
+
+			attributes = append(attributes, semconv.ErrorTypeKey.String(strconv.Itoa(status)))
+		}
+
+		if httpError, ok := lite.ErrorOf(c); ok {
+			span.SetAttributes(ErrorIDKey.String(httpError.ID), ErrorStatusKey.Int(httpError.Status))
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+		requestSize := len(c.Request().Body())
+		span.SetAttributes(semconv.HTTPRequestBodySize(requestSize))
+
+		set := metric.WithAttributeSet(attribute.NewSet(attributes...))
+
+		i.duration.Record(ctx, time.Since(start).Seconds(), set)
+		i.requestSize.Record(ctx, int64(requestSize), set)
+
+		if responseSize, ok := lite.ResponseBodySize(c); ok {
+			span.SetAttributes(semconv.HTTPResponseBodySize(responseSize))
+			i.responseSize.Record(ctx, int64(responseSize), set)
+		}
+
+		return err
+	}
+}
+
+// requestAttributes returns the attributes of the server span of a request known before it is handled
+func requestAttributes(c *fiber.Ctx, route lite.RouteInfo) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(c.Method()),
+		semconv.HTTPRoute(route.Path),
+		semconv.URLPath(c.Path()),
+		semconv.URLScheme(c.Protocol()),
+		semconv.ClientAddress(c.IP()),
+	}
+
+	host, port, err := net.SplitHostPort(c.Hostname())
+	if err != nil {
+		host = c.Hostname()
+	}
+
+	if host != "" {
+		attributes = append(attributes, semconv.ServerAddress(host))
+	}
+
+	if port, err := strconv.Atoi(port); err == nil {
+		attributes = append(attributes, semconv.ServerPort(port))
+	}
+
+	if version, ok := strings.CutPrefix(string(c.Request().Header.Protocol()), "HTTP/"); ok {
+		attributes = append(attributes, semconv.NetworkProtocolVersion(version))
+	}
+
+	if userAgent := c.Get(fiber.HeaderUserAgent); userAgent != "" {
+		attributes = append(attributes, semconv.UserAgentOriginal(userAgent))
+	}
+
+	return attributes
+}
+
+// errorType returns the error.type attribute of an error returned by the handlers of a route
+func errorType(err error) string {
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return strconv.Itoa(fiberError.Code)
+	}
+
+	return "_OTHER"
+}
+
+// headerCarrier adapts the headers of a request to propagation.TextMapCarrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (h headerCarrier) Get(key string) string {
+	return string(h.header.Peek(key))
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, h.header.Len())
+
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
@@ -0,0 +1,189 @@
+package otel
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-lite/lite"
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type getItemRequest struct {
+	ID string `lite:"path=id"`
+}
+
+type item struct {
+	ID string `json:"id"`
+}
+
+func newInstrumentedApp(t *testing.T) (*lite.App, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+
+	instrumentation, err := New(Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(t, err)
+
+	app := lite.New()
+	app.Instrument(instrumentation)
+
+	return app, spans, reader
+}
+
+func TestInstrumentation_Traces(t *testing.T) {
+	app, spans, _ := newInstrumentedApp(t)
+
+	var handlerSpan trace.SpanContext
+
+	lite.Get(app, "/items/:id", func(c *lite.ContextWithRequest[getItemRequest]) (item, error) {
+		handlerSpan = trace.SpanContextFromContext(c.Context())
+
+		req, err := c.Requests()
+		if err != nil {
+			return item{}, err
+		}
+
+		switch req.ID {
+		case "0":
+			return item{}, errors.NewNotFoundError("item not found")
+		case "crash":
+			return item{}, errors.NewInternalServerError()
+		}
+
+		return item{ID: req.ID}, nil
+	}).OperationID("getItem")
+
+	lite.Post(app, "/items", func(_ *lite.ContextNoRequest) (item, error) {
+		return item{ID: "new"}, nil
+	}).OperationID("")
+
+	req := httptest.NewRequest("GET", "/items/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("User-Agent", "lite-test")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	ended := spans.GetSpans()
+	require.Len(t, ended, 1)
+
+	span := ended[0]
+	assert.Equal(t, "getItem", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	attributes := attribute.NewSet(span.Attributes...)
+	for _, expected := range []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String("GET"),
+		semconv.HTTPRoute("/items/:id"),
+		semconv.URLPath("/items/42"),
+		semconv.URLScheme("http"),
+		semconv.HTTPResponseStatusCode(200),
+		semconv.UserAgentOriginal("lite-test"),
+		semconv.HTTPResponseBodySize(len(`{"id":"42"}` + "\n")),
+	} {
+		value, ok := attributes.Value(expected.Key)
+		assert.True(t, ok, expected.Key)
+		assert.Equal(t, expected.Value, value, expected.Key)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		code   codes.Code
+	}{
+		{path: "/items/0", status: 404, code: codes.Unset},
+		{path: "/items/crash", status: 500, code: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			spans.Reset()
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			ended := spans.GetSpans()
+			require.Len(t, ended, 1)
+			assert.Equal(t, tt.code, ended[0].Status.Code)
+
+			attributes := attribute.NewSet(ended[0].Attributes...)
+
+			status, ok := attributes.Value(ErrorStatusKey)
+			assert.True(t, ok)
+			assert.Equal(t, int64(tt.status), status.AsInt64())
+
+			id, ok := attributes.Value(ErrorIDKey)
+			assert.True(t, ok)
+			assert.NotEmpty(t, id.AsString())
+		})
+	}
+
+	spans.Reset()
+
+	_, err = app.Test(httptest.NewRequest("POST", "/items", strings.NewReader("{}")))
+	require.NoError(t, err)
+	require.Len(t, spans.GetSpans(), 1)
+	assert.Equal(t, "POST /items", spans.GetSpans()[0].Name)
+}
+
+func TestInstrumentation_Metrics(t *testing.T) {
+	app, _, reader := newInstrumentedApp(t)
+
+	lite.Post(app, "/items", func(_ *lite.ContextNoRequest) (item, error) {
+		return item{ID: "new"}, nil
+	})
+
+	for range 2 {
+		_, err := app.Test(httptest.NewRequest("POST", "/items", strings.NewReader(`{"id":"new"}`)))
+		require.NoError(t, err)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &metrics))
+	require.Len(t, metrics.ScopeMetrics, 1)
+	assert.Equal(t, ScopeName, metrics.ScopeMetrics[0].Scope.Name)
+
+	histograms := map[string]metricdata.Aggregation{}
+	for _, m := range metrics.ScopeMetrics[0].Metrics {
+		histograms[m.Name] = m.Data
+	}
+
+	duration, ok := histograms[semconv.HTTPServerRequestDurationName].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(2), duration.DataPoints[0].Count)
+
+	route, ok := duration.DataPoints[0].Attributes.Value(semconv.HTTPRouteKey)
+	assert.True(t, ok)
+	assert.Equal(t, "/items", route.AsString())
+
+	requestSize, ok := histograms[semconv.HTTPServerRequestBodySizeName].(metricdata.Histogram[int64])
+	require.True(t, ok)
+	assert.Equal(t, int64(2*len(`{"id":"new"}`)), requestSize.DataPoints[0].Sum)
+
+	responseSize, ok := histograms[semconv.HTTPServerResponseBodySizeName].(metricdata.Histogram[int64])
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), responseSize.DataPoints[0].Count)
+}
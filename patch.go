@@ -0,0 +1,572 @@
+package lite
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchBody is implemented by the patch documents decoded from the body of a request
+type patchBody interface {
+	// patchContentType returns the media type of the patch documents
+	patchContentType() string
+
+	// patchSchema returns the schema of the patch documents, documented in the components of the spec
+	patchSchema(s *App) (*openapi3.SchemaRef, error)
+}
+
+// MergePatch is a JSON Merge Patch (RFC 7396) of a T, decoded from an application/merge-patch+json body:
+// the members of the document replace those of the T, and its null members remove them.
+// It tells the members set by the client, to null or not, from the omitted ones.
+//
+//	type PatchItemRequest struct {
+//		ID    string               `lite:"path=id"`
+//		Patch lite.MergePatch[Item] `lite:"req=body"`
+//	}
+type MergePatch[T any] struct {
+	document map[string]any
+}
+
+func (p *MergePatch[T]) UnmarshalJSON(data []byte) error {
+	document, err := decodeJSONValue(data)
+	if err != nil {
+		return errors.NewBadRequestError("invalid merge patch: " + err.Error())
+	}
+
+	object, ok := document.(map[string]any)
+	if !ok {
+		return errors.NewBadRequestError("invalid merge patch: expected a JSON object")
+	}
+
+	p.document = object
+
+	return nil
+}
+
+func (p MergePatch[T]) MarshalJSON() ([]byte, error) {
+	if p.document == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(p.document)
+}
+
+// Has reports whether the patch sets the member at path, e.g. Has("address", "city"), to null or not
+func (p MergePatch[T]) Has(path ...string) bool {
+	_, ok := p.lookup(path)
+
+	return ok
+}
+
+// IsNull reports whether the patch removes the member at path, setting it to null
+func (p MergePatch[T]) IsNull(path ...string) bool {
+	value, ok := p.lookup(path)
+
+	return ok && value == nil
+}
+
+func (p MergePatch[T]) lookup(path []string) (any, bool) {
+	var value any = p.document
+
+	for _, name := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, len(path) > 0
+}
+
+// Apply applies the patch to dst, replaced with the patched value once it satisfies the validation rules of T.
+// The fields of T not encoded in JSON are reset.
+func (p MergePatch[T]) Apply(dst *T) error {
+	target, err := toJSONValue(*dst)
+	if err != nil {
+		return err
+	}
+
+	return applyJSONValue(mergePatch(target, p.document), dst)
+}
+
+// mergePatch applies a merge patch to target (RFC 7396 section 2)
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+
+			continue
+		}
+
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+func (MergePatch[T]) patchContentType() string {
+	return mergePatchContentType
+}
+
+// patchSchema documents the schema of T, whose members are all optional and nullable
+func (MergePatch[T]) patchSchema(s *App) (*openapi3.SchemaRef, error) {
+	name := tagFromType(new(T)) + "MergePatch"
+
+	if _, ok := s.OpenAPISpec.Components.Schemas[name]; !ok {
+		schema, err := generatorNewSchemaRefForValue(new(T), openapi3.Schemas{})
+		if err != nil {
+			return nil, err
+		}
+
+		optional(schema.Value)
+
+		s.OpenAPISpec.Components.Schemas[name] = schema
+	}
+
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, &openapi3.Schema{}), nil
+}
+
+// optional makes the properties of a schema, and of its inline object properties, optional and nullable
+func optional(schema *openapi3.Schema) {
+	schema.Required = nil
+
+	for _, property := range schema.Properties {
+		if property.Value == nil {
+			continue
+		}
+
+		property.Value.Nullable = true
+
+		if property.Ref == "" && property.Value.Type.Is(openapi3.TypeObject) {
+			optional(property.Value)
+		}
+	}
+}
+
+// JSONPatch is a JSON Patch (RFC 6902) of a T, decoded from an application/json-patch+json body:
+// a sequence of operations applied in order to a JSON document.
+type JSONPatch[T any] []PatchOperation
+
+// PatchOperation is an operation of a JSON Patch, whose paths are JSON Pointers (RFC 6901), e.g. /tags/0
+type PatchOperation struct {
+	Op    string          `json:"op"`              // add, remove, replace, move, copy or test
+	Path  string          `json:"path"`            // Location of the operation
+	From  string          `json:"from,omitempty"`  // Location of the value moved or copied
+	Value json.RawMessage `json:"value,omitempty"` // Value added, replaced or tested
+}
+
+func (p *JSONPatch[T]) UnmarshalJSON(data []byte) error {
+	var operations []PatchOperation
+
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return errors.NewBadRequestError("invalid JSON patch: " + err.Error())
+	}
+
+	for i, operation := range operations {
+		if err := operation.check(); err != nil {
+			return errors.NewBadRequestError(fmt.Sprintf("invalid JSON patch operation %d: %s", i, err))
+		}
+	}
+
+	*p = operations
+
+	return nil
+}
+
+// check checks the members of an operation, and the syntax of its JSON Pointers
+func (o PatchOperation) check() error {
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return fmt.Errorf("missing value of %s operation", o.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(o.From); err != nil {
+			return err
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown operation %q", o.Op)
+	}
+
+	_, err := parsePointer(o.Path)
+
+	return err
+}
+
+// Apply applies the operations of the patch to dst, replaced with the patched value
+// once it satisfies the validation rules of T. dst is left unchanged when an operation fails,
+// with a 409 Conflict error when a test operation fails, 422 Unprocessable Entity otherwise.
+// The fields of T not encoded in JSON are reset.
+func (p JSONPatch[T]) Apply(dst *T) error {
+	document, err := toJSONValue(*dst)
+	if err != nil {
+		return err
+	}
+
+	for i, operation := range p {
+		document, err = operation.apply(document)
+		if err != nil {
+			if stderrors.As(err, new(errors.HTTPError)) {
+				return err
+			}
+
+			return errors.NewError(StatusUnprocessableEntity, fmt.Sprintf("cannot apply JSON patch operation %d: %s", i, err))
+		}
+	}
+
+	return applyJSONValue(document, dst)
+}
+
+func (o PatchOperation) apply(document any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace":
+		value, err := decodeJSONValue(o.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return updateJSONValue(document, path, o.Op, value)
+	case "remove":
+		return updateJSONValue(document, path, o.Op, nil)
+	case "move", "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := getJSONValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "move" {
+			if strings.HasPrefix(o.Path+"/", o.From+"/") && o.Path != o.From {
+				return nil, fmt.Errorf("cannot move %s into one of its children", o.From)
+			}
+
+			if document, err = updateJSONValue(document, from, "remove", nil); err != nil {
+				return nil, err
+			}
+		} else if value, err = toJSONValue(value); err != nil {
+			return nil, err
+		}
+
+		return updateJSONValue(document, path, "add", value)
+	case "test":
+		value, err := getJSONValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+
+		expected, err := decodeJSONValue(o.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equalJSONValues(value, expected) {
+			return nil, errors.NewConflictError("test of " + o.Path + " failed")
+		}
+
+		return document, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", o.Op)
+	}
+}
+
+func (JSONPatch[T]) patchContentType() string {
+	return jsonPatchContentType
+}
+
+// patchSchema documents the schema of the JSON patches, shared by all the routes
+func (JSONPatch[T]) patchSchema(s *App) (*openapi3.SchemaRef, error) {
+	const name = "JSONPatch"
+
+	if _, ok := s.OpenAPISpec.Components.Schemas[name]; !ok {
+		operation := openapi3.NewObjectSchema().
+			WithProperty("op", openapi3.NewStringSchema().WithEnum("add", "remove", "replace", "move", "copy", "test")).
+			WithProperty("path", openapi3.NewStringSchema()).
+			WithProperty("from", openapi3.NewStringSchema()).
+			WithPropertyRef("value", openapi3.NewSchemaRef("", &openapi3.Schema{}))
+		operation.Required = []string{"op", "path"}
+
+		s.OpenAPISpec.Components.Schemas[name] = openapi3.NewArraySchema().WithItems(operation).NewRef()
+	}
+
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, &openapi3.Schema{}), nil
+}
+
+// parsePointer returns the reference tokens of a JSON Pointer (RFC 6901), none for the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// getJSONValue returns the value at path in document
+func getJSONValue(document any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := document.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+
+			document = value
+		case []any:
+			i, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			document = container[i]
+		default:
+			return nil, fmt.Errorf("cannot get %q of a scalar", token)
+		}
+	}
+
+	return document, nil
+}
+
+// updateJSONValue adds, removes or replaces the value at path in document, and returns the updated document
+func updateJSONValue(document any, path []string, op string, value any) (any, error) {
+	if len(path) == 0 {
+		if op == "remove" {
+			return nil, fmt.Errorf("cannot remove the whole document")
+		}
+
+		return value, nil
+	}
+
+	token := path[0]
+
+	switch container := document.(type) {
+	case map[string]any:
+		current, ok := container[token]
+
+		if len(path) > 1 {
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+
+			updated, err := updateJSONValue(current, path[1:], op, value)
+			if err != nil {
+				return nil, err
+			}
+
+			container[token] = updated
+
+			return container, nil
+		}
+
+		switch {
+		case op == "add":
+			container[token] = value
+		case !ok:
+			return nil, fmt.Errorf("member %q not found", token)
+		case op == "remove":
+			delete(container, token)
+		default:
+			container[token] = value
+		}
+
+		return container, nil
+	case []any:
+		if len(path) == 1 && op == "add" {
+			if token == "-" {
+				return append(container, value), nil
+			}
+
+			i, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+
+			return append(container[:i], append([]any{value}, container[i:]...)...), nil
+		}
+
+		i, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(path) > 1:
+			updated, err := updateJSONValue(container[i], path[1:], op, value)
+			if err != nil {
+				return nil, err
+			}
+
+			container[i] = updated
+		case op == "remove":
+			return append(container[:i], container[i+1:]...), nil
+		default:
+			container[i] = value
+		}
+
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot %s %q of a scalar", op, token)
+	}
+}
+
+// arrayIndex parses an array index of a JSON Pointer, which must not exceed maxIndex
+func arrayIndex(token string, maxIndex int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	if i > maxIndex {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+
+	return i, nil
+}
+
+// decodeJSONValue decodes a JSON document into maps, slices and scalars, keeping the precision of its numbers
+func decodeJSONValue(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+
+	return value, nil
+}
+
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSONValue(data)
+}
+
+// equalJSONValues compares JSON values, whose numbers are equal when their values are, e.g. 1 and 1.0
+func equalJSONValues(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equalJSONValues(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equalJSONValues(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// applyJSONValue decodes a patched document into dst, once it satisfies the validation rules of T
+func applyJSONValue[T any](document any, dst *T) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	var patched T
+
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return errors.NewError(StatusUnprocessableEntity, "invalid patched document: "+err.Error())
+	}
+
+	validator, err := newBodyValidator(reflect.TypeOf(patched), "json", map[reflect.Type]*bodyValidator{})
+	if err != nil {
+		return err
+	}
+
+	var fieldErrors []errors.FieldError
+
+	if validator != nil {
+		validator.validate(reflect.ValueOf(&patched).Elem(), "", &fieldErrors)
+	}
+
+	if len(fieldErrors) > 0 {
+		return errors.NewValidationError(fieldErrors...)
+	}
+
+	*dst = patched
+
+	return nil
+}
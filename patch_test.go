@@ -0,0 +1,202 @@
+package lite
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchedAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type patchedItem struct {
+	Name    string          `json:"name"    validate:"max=10"`
+	Note    *string         `json:"note"`
+	Tags    []string        `json:"tags"`
+	Count   int             `json:"count"`
+	Address *patchedAddress `json:"address"`
+}
+
+func newPatchedItem() patchedItem {
+	note := "fragile"
+
+	return patchedItem{
+		Name:    "box",
+		Note:    &note,
+		Tags:    []string{"a", "b"},
+		Count:   1,
+		Address: &patchedAddress{City: "Paris", Country: "FR"},
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	app := New()
+
+	type request struct {
+		Patch MergePatch[patchedItem] `lite:"req=body"`
+	}
+
+	Patch(app, "/items/:id", func(c *ContextWithRequest[request]) (patchedItem, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return patchedItem{}, err
+		}
+
+		item := newPatchedItem()
+
+		if req.Patch.Has("count") {
+			c.Set("X-Count-Set", "true")
+		}
+
+		return item, req.Patch.Apply(&item)
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name:   "merge",
+			body:   `{"name":"crate","note":null,"address":{"city":"Lyon"}}`,
+			status: 200,
+			want:   `{"name":"crate","note":null,"tags":["a","b"],"count":1,"address":{"city":"Lyon","country":"FR"}}`,
+		},
+		{
+			name:   "remove object",
+			body:   `{"address":null,"tags":["c"],"count":0}`,
+			status: 200,
+			want:   `{"name":"box","note":"fragile","tags":["c"],"count":0,"address":null}`,
+		},
+		{name: "invalid patched value", body: `{"name":"a very long name"}`, status: 422},
+		{name: "wrong type", body: `{"count":"one"}`, status: 422},
+		{name: "not an object", body: `["name"]`, status: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/items/1", strings.NewReader(tt.body))
+			req.Header.Set(HeaderContentType, "application/merge-patch+json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			if tt.want != "" {
+				body, _ := io.ReadAll(resp.Body)
+				assert.JSONEq(t, tt.want, string(body))
+			}
+		})
+	}
+
+	requestBody := app.OpenAPISpec.Paths.Find("/items/{id}").Patch.RequestBody.Value
+	assert.Equal(t, "#/components/schemas/patchedItemMergePatch",
+		requestBody.Content["application/merge-patch+json"].Schema.Ref)
+
+	schema := app.OpenAPISpec.Components.Schemas["patchedItemMergePatch"].Value
+	assert.Empty(t, schema.Required)
+	assert.True(t, schema.Properties["name"].Value.Nullable)
+}
+
+func TestMergePatch_Presence(t *testing.T) {
+	var patch MergePatch[patchedItem]
+
+	require.NoError(t, patch.UnmarshalJSON([]byte(`{"note":null,"address":{"city":"Lyon"}}`)))
+
+	assert.True(t, patch.Has("note"))
+	assert.True(t, patch.IsNull("note"))
+	assert.True(t, patch.Has("address", "city"))
+	assert.False(t, patch.IsNull("address"))
+	assert.False(t, patch.Has("address", "country"))
+	assert.False(t, patch.Has("name"))
+	assert.False(t, patch.Has())
+}
+
+func TestJSONPatch(t *testing.T) {
+	app := New()
+
+	type request struct {
+		Patch JSONPatch[patchedItem] `lite:"req=body"`
+	}
+
+	Patch(app, "/items/:id", func(c *ContextWithRequest[request]) (patchedItem, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return patchedItem{}, err
+		}
+
+		item := newPatchedItem()
+
+		return item, req.Patch.Apply(&item)
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name: "operations",
+			body: `[
+				{"op":"test","path":"/count","value":1.0},
+				{"op":"replace","path":"/name","value":"crate"},
+				{"op":"add","path":"/tags/1","value":"z"},
+				{"op":"add","path":"/tags/-","value":"end"},
+				{"op":"remove","path":"/tags/0"},
+				{"op":"copy","from":"/address/city","path":"/note"},
+				{"op":"move","from":"/address/country","path":"/address/city"}
+			]`,
+			status: 200,
+			want:   `{"name":"crate","note":"Paris","tags":["z","b","end"],"count":1,"address":{"city":"FR","country":""}}`,
+		},
+		{name: "escaped pointer", body: `[{"op":"add","path":"/address/a~1b","value":"x"}]`, status: 200},
+		{name: "failed test", body: `[{"op":"test","path":"/name","value":"crate"}]`, status: 409},
+		{name: "missing member", body: `[{"op":"replace","path":"/unknown","value":1}]`, status: 422},
+		{name: "index out of bounds", body: `[{"op":"remove","path":"/tags/5"}]`, status: 422},
+		{name: "move into child", body: `[{"op":"move","from":"/address","path":"/address/city"}]`, status: 422},
+		{name: "invalid patched value", body: `[{"op":"replace","path":"/name","value":"a very long name"}]`, status: 422},
+		{name: "unknown operation", body: `[{"op":"merge","path":"/name"}]`, status: 400},
+		{name: "missing value", body: `[{"op":"add","path":"/name"}]`, status: 400},
+		{name: "invalid pointer", body: `[{"op":"remove","path":"name"}]`, status: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/items/1", strings.NewReader(tt.body))
+			req.Header.Set(HeaderContentType, "application/json-patch+json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.status, resp.StatusCode, string(body))
+
+			if tt.want != "" {
+				assert.JSONEq(t, tt.want, string(body))
+			}
+		})
+	}
+
+	requestBody := app.OpenAPISpec.Paths.Find("/items/{id}").Patch.RequestBody.Value
+	assert.Equal(t, "#/components/schemas/JSONPatch", requestBody.Content["application/json-patch+json"].Schema.Ref)
+	assert.True(t, app.OpenAPISpec.Components.Schemas["JSONPatch"].Value.Type.Is("array"))
+}
+
+func TestCodecRegistry_LookupSuffix(t *testing.T) {
+	codec, ok := defaultCodecs().lookup("application/merge-patch+json; charset=utf-8")
+	require.True(t, ok)
+	assert.Equal(t, "application/json", codec.MediaType())
+
+	codec, ok = defaultCodecs().lookup("application/atom+xml")
+	require.True(t, ok)
+	assert.Equal(t, "application/xml", codec.MediaType())
+}
@@ -1,16 +1,26 @@
 package lite
 
 import (
+	"context"
+	"log/slog"
+	"net/http"
+	"reflect"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
 )
 
 type Route[T, B any] struct {
+	app         *App
+	registered  *registeredRoute
 	operation   *openapi3.Operation
 	path        string
 	method      string
 	contentType string
+	produces    []string
 	statusCode  int
 }
 
@@ -39,16 +49,282 @@ func (r Route[ResponseBody, Request]) Deprecated() Route[ResponseBody, Request]
 }
 
 func (r Route[ResponseBody, Request]) AddTags(tags ...string) Route[ResponseBody, Request] {
-	r.operation.Tags = tags
+	r.operation.Tags = append(r.operation.Tags, tags...)
 
 	return r
 }
 
+// SetResponseContentType sets the content type of the response of the route, application/json by default
 func (r Route[ResponseBody, Request]) SetResponseContentType(contentType string) Route[ResponseBody, Request] {
 	r.operation.Responses.Value(strconv.Itoa(r.statusCode)).Value.Content[contentType] = r.operation.Responses.
 		Value(strconv.Itoa(r.statusCode)).Value.Content[r.contentType]
 
 	delete(r.operation.Responses.Value(strconv.Itoa(r.statusCode)).Value.Content, r.contentType)
 
+	r.contentType = contentType
+
+	if r.registered != nil {
+		r.registered.contentType = contentType
+	}
+
+	return r
+}
+
+// Produces declares the content types the route can respond with, in order of preference.
+// The response is serialized in the one preferred by the Accept header of the request,
+// the first one when the request has none, and the route responds 406 Not Acceptable
+// when none of them is accepted. Each content type is documented in the OpenAPI spec.
+func (r Route[ResponseBody, Request]) Produces(contentTypes ...string) Route[ResponseBody, Request] {
+	if len(contentTypes) == 0 {
+		return r
+	}
+
+	if response := r.operation.Responses.Value(strconv.Itoa(r.statusCode)); response != nil &&
+		response.Value.Content != nil {
+		mediaType := response.Value.Content[r.contentType]
+
+		response.Value.Content = openapi3.Content{}
+		for _, contentType := range contentTypes {
+			response.Value.Content[contentType] = mediaType
+		}
+	}
+
+	notAcceptable, err := r.app.createErrorResponse(errors.NewError(http.StatusNotAcceptable))
+	if err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	r.operation.AddResponse(http.StatusNotAcceptable, notAcceptable)
+
+	r.contentType = contentTypes[0]
+	r.produces = contentTypes
+
+	if r.registered != nil {
+		r.registered.contentType = contentTypes[0]
+		r.registered.produces = contentTypes
+	}
+
+	return r
+}
+
+// Status sets the status code of the successful response of the route.
+// It is written before calling the handler, which can still change it with Context.Status,
+// and replaces the default one (201 for POST, 204 for DELETE without body, 200 otherwise) in the OpenAPI spec.
+func (r Route[ResponseBody, Request]) Status(statusCode int) Route[ResponseBody, Request] {
+	response := r.operation.Responses.Value(strconv.Itoa(r.statusCode))
+	r.operation.Responses.Delete(strconv.Itoa(r.statusCode))
+
+	if response != nil {
+		if !bodyAllowed(statusCode) {
+			response.Value.Content = nil
+		}
+
+		r.operation.Responses.Set(strconv.Itoa(statusCode), response)
+	}
+
+	r.statusCode = statusCode
+
+	if r.registered != nil {
+		r.registered.info.StatusCode = statusCode
+	}
+
+	return r
+}
+
+// AddResponse documents an additional successful response of the route, e.g. 206 Partial Content next to 200 OK
+// or 303 See Other next to 201 Created. body is a value of the type returned with this status code,
+// nil when the response has no body, and headers are the names of the headers set with it.
+// The handler selects the response at runtime with Context.Status.
+func (r Route[ResponseBody, Request]) AddResponse(
+	statusCode int,
+	description string,
+	body any,
+	headers ...string,
+) Route[ResponseBody, Request] {
+	response := openapi3.NewResponse().WithDescription(description)
+
+	if body != nil && bodyAllowed(statusCode) {
+		contentTypes := r.produces
+		if len(contentTypes) == 0 {
+			contentTypes = []string{r.contentType}
+		}
+
+		content, err := newResponseContent(r.app, contentTypes, body, reflect.TypeOf(body))
+		if err != nil {
+			slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+			panic(err)
+		}
+
+		response.WithContent(content)
+	}
+
+	if len(headers) > 0 {
+		response.Headers = headerRefs(headers...)
+	}
+
+	r.operation.AddResponse(statusCode, response)
+
+	return r
+}
+
+// Security requires the security scheme name, with scopes, on the route.
+// The security scheme must be declared with App.AddSecurityScheme, e.g. an OAuth2 scheme with its flows.
+// When an Authenticator of the scheme is added (see App.AddAuthenticator), the requests are authenticated
+// and those whose identity lacks one of the scopes are rejected with 403 Forbidden.
+func (r Route[ResponseBody, Request]) Security(name string, scopes ...string) Route[ResponseBody, Request] {
+	addSecurityRequirement(r.operation, name, scopes)
+
+	if err := documentForbidden(r.app, r.operation); err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	return r
+}
+
+// ETag generates the entity tags of the successful responses of the route from their body,
+// unless the handler sets the ETag header itself. The GET and HEAD requests whose If-None-Match header
+// matches the entity tag are answered with 304 Not Modified, and the PUT, PATCH and DELETE routes
+// document the If-Match header checked by Context.IfMatch.
+func (r Route[ResponseBody, Request]) ETag(mode ETagMode) Route[ResponseBody, Request] {
+	r.registered.etag = mode
+
+	r.documentETag()
+
+	return r
+}
+
+// RequireIfMatch rejects the PUT, PATCH and DELETE requests without If-Match header
+// with 428 Precondition Required, before the handler runs
+func (r Route[ResponseBody, Request]) RequireIfMatch() Route[ResponseBody, Request] {
+	r.registered.requireIfMatch = true
+
+	r.documentETag()
+
+	return r
+}
+
+func (r Route[ResponseBody, Request]) documentETag() {
+	if err := documentETag(r.app, r.operation, r.method, r.statusCode, r.registered); err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+}
+
+// Idempotent stores in store the first response of the requests with an Idempotency-Key header
+// and replays it for the retries of the request with the same key, which are not handled again.
+// A key reused by a request with another method, URL or body is rejected with 422 Unprocessable Entity,
+// and a key whose first request is still in flight with 409 Conflict. Server errors are not stored,
+// so that the request can be retried. Keys are scoped to the route and to the authenticated subject.
+// GET and HEAD routes, safe, ignore the header.
+func (r Route[ResponseBody, Request]) Idempotent(store IdempotencyStore) Route[ResponseBody, Request] {
+	r.registered.idempotency = store
+
+	if err := documentIdempotency(r.app, r.operation, r.method, r.registered); err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
+	return r
+}
+
+// Timeout cancels the context of the requests of the route, see Context.Context, after timeout.
+// The handlers are expected to pass the context to the calls they make: once the timeout passed,
+// the request is answered with 503 Service Unavailable, whatever the handler returns.
+//
+// The context of every request is also cancelled when its client disconnects, on Unix plain TCP connections:
+// TLS connections are never watched, and a client half-closing the connection after its request
+// is considered disconnected.
+func (r Route[ResponseBody, Request]) Timeout(timeout time.Duration) Route[ResponseBody, Request] {
+	r.registered.timeout = timeout
+
+	if err := documentTimeout(r.app, r.operation, timeout); err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi response", slog.Any("error", err))
+		panic(err)
+	}
+
 	return r
 }
+
+// headerRefs documents string response headers
+func headerRefs(names ...string) openapi3.Headers {
+	headers := make(openapi3.Headers, len(names))
+
+	for _, name := range names {
+		headers[name] = newHeaderRef()
+	}
+
+	return headers
+}
+
+// newHeaderRef documents a string response header
+func newHeaderRef() *openapi3.HeaderRef {
+	return &openapi3.HeaderRef{
+		Value: &openapi3.Header{
+			Parameter: openapi3.Parameter{
+				Schema: openapi3.NewStringSchema().NewRef(),
+			},
+		},
+	}
+}
+
+// RouteInfo describes a route registered on an App
+type RouteInfo struct {
+	Method       string
+	Path         string // Fiber path, e.g. /users/:id
+	OperationID  string
+	StatusCode   int
+	ContentType  string       // Content type of the successful response, e.g. text/event-stream for SSE routes
+	RequestType  reflect.Type // Type of the request struct, an interface type for routes without request
+	ResponseType reflect.Type
+	Streamed     bool // Whether the response body is streamed, see Stream, NDJSON, JSONArray and File
+}
+
+type registeredRoute struct {
+	info      RouteInfo
+	operation *openapi3.Operation
+	binder    *binder // nil when the request is not a struct
+
+	// security schemes authenticating the route, resolved on its first request, see registeredRoute.authenticate
+	securityOnce sync.Once
+	security     []securityField
+
+	// content types of the response, see Route.Produces
+	contentType string
+	produces    []string
+
+	// entity tags of the responses and conditional requests, see Route.ETag and Route.RequireIfMatch
+	etag           ETagMode
+	requireIfMatch bool
+
+	// store of the responses of the requests with an Idempotency-Key header, see Route.Idempotent
+	idempotency IdempotencyStore
+
+	// time after which the context of the requests is cancelled, see Route.Timeout
+	timeout time.Duration
+
+	// whether the context of the requests is derived before the middleware run, see withRequestContext.
+	// The SSE and WebSocket routes hand the context of their requests to their streams instead.
+	scoped bool
+}
+
+// Routes returns the routes registered on the App, in registration order
+func (s *App) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(s.routes))
+
+	for _, route := range s.routes {
+		routes = append(routes, route.describe())
+	}
+
+	return routes
+}
+
+// describe returns the RouteInfo of a registered route, with its current operation id
+func (r *registeredRoute) describe() RouteInfo {
+	info := r.info
+	info.OperationID = r.operation.OperationID
+	info.ContentType = r.contentType
+
+	return info
+}
@@ -1,6 +1,10 @@
 package lite
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -95,3 +99,91 @@ func TestRoute_SetResponseContentType(t *testing.T) {
 	assert.Nil(t, oldExists.Value.Content["application/json"])
 	assert.NotNil(t, newExists.Value.Content["application/xml"])
 }
+
+func TestApp_Routes(t *testing.T) {
+	app := New()
+
+	Get(app, "/foo/:id", func(c *ContextWithRequest[requestPath]) (responsePath, error) {
+		return responsePath{}, nil
+	}).OperationID("getFoo")
+
+	api := app.Group("/api")
+
+	Post(api, "/bar", func(c *ContextNoRequest) (string, error) {
+		return "", nil
+	})
+
+	routes := app.Routes()
+
+	assert.Len(t, routes, 2)
+	assert.Equal(t, RouteInfo{
+		Method:       "GET",
+		Path:         "/foo/:id",
+		OperationID:  "getFoo",
+		StatusCode:   200,
+		ContentType:  "application/json",
+		RequestType:  reflect.TypeOf(requestPath{}),
+		ResponseType: reflect.TypeOf(responsePath{}),
+	}, routes[0])
+	assert.Equal(t, "/api/bar", routes[1].Path)
+	assert.Equal(t, "POST/api/bar", routes[1].OperationID)
+	assert.Equal(t, reflect.Interface, routes[1].RequestType.Kind())
+	assert.Equal(t, reflect.TypeOf(""), routes[1].ResponseType)
+}
+
+func TestRoute_Status(t *testing.T) {
+	app := New()
+
+	route := Post(app, "/foo", func(c *ContextNoRequest) (responsePath, error) {
+		return responsePath{ID: 1}, nil
+	}).Status(http.StatusAccepted)
+
+	assert.Nil(t, route.operation.Responses.Value("201"))
+	assert.NotNil(t, route.operation.Responses.Value("202").Value.Content["application/json"])
+	assert.Equal(t, http.StatusAccepted, app.Routes()[0].StatusCode)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/foo", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id":1,"message":""}`, string(body))
+
+	route = route.Status(http.StatusNoContent)
+
+	assert.Nil(t, route.operation.Responses.Value("202"))
+	assert.Nil(t, route.operation.Responses.Value("204").Value.Content)
+}
+
+func TestRoute_AddResponse(t *testing.T) {
+	app := New()
+
+	route := Get(app, "/foo", func(c *ContextNoRequest) (responsePath, error) {
+		c.Status(http.StatusPartialContent)
+
+		return responsePath{ID: 1}, nil
+	}).
+		AddResponse(http.StatusPartialContent, "Partial Content", responseQuery{}, HeaderContentRange).
+		AddResponse(http.StatusSeeOther, "See Other", nil, HeaderLocation)
+
+	partial := route.operation.Responses.Value("206").Value
+	assert.Equal(t, "Partial Content", *partial.Description)
+	assert.Equal(t, "#/components/schemas/responseQuery", partial.Content["application/json"].Schema.Ref)
+	assert.NotNil(t, partial.Headers[HeaderContentRange])
+	assert.NotNil(t, app.OpenAPISpec.Components.Schemas["responseQuery"])
+
+	seeOther := route.operation.Responses.Value("303").Value
+	assert.Nil(t, seeOther.Content)
+	assert.NotNil(t, seeOther.Headers[HeaderLocation])
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/foo", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+}
+
+func TestGetStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusCreated, getStatusCode(http.MethodPost, reflect.TypeOf(responsePath{})))
+	assert.Equal(t, http.StatusNoContent, getStatusCode(http.MethodDelete, reflect.TypeOf(struct{}{})))
+	assert.Equal(t, http.StatusOK, getStatusCode(http.MethodDelete, reflect.TypeOf(responsePath{})))
+	assert.Equal(t, http.StatusOK, getStatusCode(http.MethodGet, reflect.TypeOf(responsePath{})))
+}
@@ -1,20 +1,22 @@
 package lite
 
 import (
-	"encoding/json"
-	"encoding/xml"
 	"fmt"
-	"net/url"
 	"reflect"
 
 	"github.com/valyala/fasthttp"
 )
 
-func serializeResponse(ctx *fasthttp.RequestCtx, src any) error {
+func serializeResponse(ctx *fasthttp.RequestCtx, codecs codecRegistry, src any) error {
 	if src == nil {
 		return nil
 	}
 
+	// streamed bodies are written through the body stream of the response, without being buffered
+	if body, ok := src.(streamedBody); ok {
+		return writeStreamedBody(ctx, body, func() {})
+	}
+
 	srcVal := reflect.ValueOf(src)
 	if srcVal.Kind() == reflect.Ptr {
 		srcVal = srcVal.Elem()
@@ -38,62 +40,23 @@ func serializeResponse(ctx *fasthttp.RequestCtx, src any) error {
 		reflect.Array, reflect.Interface, reflect.Map, reflect.Slice, reflect.Struct:
 		fallthrough
 	default:
-		return serialize(ctx, srcVal)
+		return serialize(ctx, codecs, srcVal)
 	}
 }
 
-func serialize(ctx *fasthttp.RequestCtx, srcVal reflect.Value) error {
-	contentType := ctx.Response.Header.ContentType()
-
-	switch string(contentType) {
-	case "application/json":
-		if err := json.NewEncoder(ctx).Encode(srcVal.Interface()); err != nil {
-			ctx.Error(err.Error(), StatusInternalServerError)
-
-			return err
-		}
-	case "application/xml":
-		if err := xml.NewEncoder(ctx).Encode(srcVal.Interface()); err != nil {
-			ctx.Error(err.Error(), StatusInternalServerError)
-
-			return err
-		}
-	case "multipart/form-data", "application/x-www-form-urlencoded":
-		if form, ok := srcVal.Interface().(map[string]string); ok {
-			formData := url.Values{}
-
-			for key, value := range form {
-				formData.Set(key, value)
-			}
-
-			ctx.SetBody([]byte(formData.Encode()))
-		} else {
-			err := fmt.Errorf("expected map[string]string for form data serialization")
-			ctx.Error(err.Error(), StatusInternalServerError)
+// serialize encodes the response with the codec of its content type
+func serialize(ctx *fasthttp.RequestCtx, codecs codecRegistry, srcVal reflect.Value) error {
+	contentType := string(ctx.Response.Header.ContentType())
 
-			return err
-		}
-	case "application/octet-stream":
-		if data, ok := srcVal.Interface().([]byte); ok {
-			ctx.SetBody(data)
-		} else {
-			err := fmt.Errorf("expected []byte for octet-stream serialization")
-			ctx.Error(err.Error(), StatusInternalServerError)
+	codec, ok := codecs.lookup(contentType)
+	if !ok {
+		err := fmt.Errorf("unsupported content type: %s", contentType)
+		ctx.Error(err.Error(), StatusInternalServerError)
 
-			return err
-		}
-	case "application/pdf", "application/zip", "image/png", "image/jpeg", "image/gif", "image/webp", "image/svg+xml",
-		"image/tiff", "image/vnd.microsoft.icon", "image/vnd.wap.wbmp", "image/x-icon", "image/x-jng", "image/jpg":
-		if data, ok := srcVal.Interface().([]byte); ok {
-			ctx.SetBody(data)
-		} else {
-			err := fmt.Errorf("expected []byte for binary file serialization")
-			ctx.Error(err.Error(), StatusInternalServerError)
+		return err
+	}
 
-			return err
-		}
-	default:
-		err := fmt.Errorf("unsupported content type: %s", contentType)
+	if err := codec.Encode(ctx, srcVal.Interface()); err != nil {
 		ctx.Error(err.Error(), StatusInternalServerError)
 
 		return err
@@ -98,6 +98,13 @@ func TestSerializeResponse(t *testing.T) {
 			expectedBody: []byte{0x01, 0x02, 0x03},
 			expectedErr:  nil,
 		},
+		{
+			name:         "Binary data (image/svg+xml)",
+			src:          []byte("<svg/>"),
+			contentType:  "image/svg+xml",
+			expectedBody: []byte("<svg/>"),
+			expectedErr:  nil,
+		},
 		{
 			name:            "Binary data (image/png) with wrong type",
 			src:             1,
@@ -113,7 +120,7 @@ func TestSerializeResponse(t *testing.T) {
 			ctx := new(fasthttp.RequestCtx)
 			ctx.Response.Header.SetContentType(tt.contentType)
 
-			err := serializeResponse(ctx, tt.src)
+			err := serializeResponse(ctx, defaultCodecs(), tt.src)
 
 			if tt.expectedErr != nil {
 				assert.NotNil(t, err)
@@ -211,7 +218,7 @@ func TestSerialize(t *testing.T) {
 			ctx.Response.Header.SetContentType(tt.contentType)
 
 			srcVal := reflect.ValueOf(tt.src)
-			err := serialize(ctx, srcVal)
+			err := serialize(ctx, defaultCodecs(), srcVal)
 
 			if tt.expectedErr != nil {
 				assert.NotNil(t, err)
@@ -1,7 +1,16 @@
 package lite
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-lite/lite/errors"
@@ -12,10 +21,12 @@ import (
 
 type OpenAPIConfig struct {
 	DisableSwagger   bool                               // If true, the server will not serve the swagger ui nor the openapi json spec
-	DisableLocalSave bool                               // If true, the server will not save the openapi json spec locally
+	DisableLocalSave bool                               // If true, the server will not save the openapi yaml spec locally
 	SwaggerURL       string                             // URL to serve the swagger ui
 	UIHandler        func(specURL string) fiber.Handler // Handler to serve the openapi ui from spec url
-	YamlURL          string                             // Local path to save the openapi json spec
+	JSONURL          string                             // URL to serve the openapi json spec
+	YamlSpecURL      string                             // URL to serve the openapi yaml spec
+	YamlURL          string                             // Local path to save the openapi yaml spec
 }
 
 func NewOpenAPISpec() openapi3.T {
@@ -42,8 +53,11 @@ func NewOpenAPISpec() openapi3.T {
 }
 
 var defaultOpenAPIConfig = OpenAPIConfig{
-	SwaggerURL: "/openapi",
-	YamlURL:    "/api/openapi.yaml",
+	SwaggerURL:  "/openapi",
+	UIHandler:   DefaultOpenAPIHandler,
+	JSONURL:     "/api/openapi.json",
+	YamlSpecURL: "/api/openapi.yaml",
+	YamlURL:     "api/openapi.yaml",
 }
 
 type App struct {
@@ -52,19 +66,90 @@ type App struct {
 	OpenAPISpec   openapi3.T
 	OpenAPIConfig OpenAPIConfig
 
+	// Serializer, when set, writes the responses of the handlers instead of the codec of their content type
 	Serializer func(ctx *fasthttp.RequestCtx, response any) error
 
+	// ShutdownTimeout is the time given to the in-flight requests to complete when Run shuts the App down
+	ShutdownTimeout time.Duration
+
+	// Codecs of the request and response bodies by media type, see RegisterCodec
+	codecs codecRegistry
+
 	// OpenAPI documentation tags used for logical groupings of operations
 	// These tags will be inherited by child Routes/Groups
 	tags []string
+
+	// Authenticators of the security schemes by name, see AddAuthenticator
+	authenticators map[string]Authenticator
+
+	// Media types of the WebSocket subprotocols by name, see RegisterSubprotocol
+	subprotocols map[string]string
+
+	// Open WebSocket connections, closed when the App shuts down
+	websockets websocketRegistry
+
+	// Typed middleware of every route, see UseMiddleware
+	middleware []*Middleware
+
+	// Instrumentations of every route, see Instrument
+	instrumentations []Instrumentation
+
+	// If true, errors are written as RFC 9457 problem details
+	problemDetails bool
+
+	// Routes registered on the App, see Routes
+	routes []*registeredRoute
+
+	// Context of the requests, cancelled when the App shuts down, see ShutdownWithContext,
+	// and renewed when it starts again
+	ctxMu  sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Lifecycle hooks, see OnStart and OnShutdown
+	startHooks    []func(ctx context.Context) error
+	shutdownHooks []func(ctx context.Context) error
+
+	// OpenAPI spec frozen when the server starts
+	setupOnce   sync.Once
+	setupErr    error
+	openAPIJSON []byte
+	openAPIYAML []byte
 }
 
 func New() *App {
-	return &App{
-		App:           fiber.New(),
-		OpenAPISpec:   NewOpenAPISpec(),
-		OpenAPIConfig: defaultOpenAPIConfig,
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app := &App{
+		App:             fiber.New(),
+		OpenAPISpec:     NewOpenAPISpec(),
+		OpenAPIConfig:   defaultOpenAPIConfig,
+		ShutdownTimeout: 10 * time.Second,
+		codecs:          defaultCodecs(),
+		ctx:             ctx,
+		cancel:          cancel,
+
+		authenticators: make(map[string]Authenticator),
+		subprotocols: map[string]string{
+			"json": fiber.MIMEApplicationJSON,
+			"xml":  fiber.MIMEApplicationXML,
+		},
 	}
+
+	app.Hooks().OnShutdown(func() error {
+		app.websockets.goingAway()
+
+		return nil
+	})
+
+	// the requests see a context cancelled when the App shuts down, which middleware can extend
+	app.App.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(app.requestsContext())
+
+		return c.Next()
+	})
+
+	return app
 }
 
 // AddTags adds tags from the Server (i.e Group)
@@ -74,6 +159,46 @@ func (s *App) AddTags(tags ...string) *App {
 	return s
 }
 
+// UseProblemDetails writes the errors returned by the handlers as RFC 9457 problem details
+// (application/problem+json or application/problem+xml) instead of HTTPError.
+// It must be called before registering routes, so that their error responses are documented accordingly.
+func (s *App) UseProblemDetails() *App {
+	s.problemDetails = true
+
+	return s
+}
+
+// AddSecurityScheme declares a security scheme in the OpenAPI spec, e.g. an OAuth2 scheme with its flows
+// (see NewOAuth2SecurityScheme) or an OpenID Connect one (see openapi3.NewOIDCSecurityScheme).
+// Routes and Groups reference it by name (see Route.Security and Group.Security), as do the isauth fields
+// of the requests with the name option. It must be declared before registering the routes referencing it.
+func (s *App) AddSecurityScheme(name string, scheme *openapi3.SecurityScheme) *App {
+	s.OpenAPISpec.Components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{
+		Value: scheme,
+	}
+
+	return s
+}
+
+func (s *App) app() *App {
+	return s
+}
+
+// UseMiddleware adds typed middleware executed before the handler of every route registered afterwards,
+// and documented on them. Plain fiber.Handlers are added with Use.
+func (s *App) UseMiddleware(middleware ...*Middleware) *App {
+	s.middleware = append(s.middleware, middleware...)
+
+	return s
+}
+
+func (s *App) options() routeOptions {
+	return routeOptions{
+		tags:       s.tags,
+		middleware: s.middleware,
+	}
+}
+
 // SaveOpenAPISpec saves the OpenAPI spec to a file in YAML format
 func (s *App) SaveOpenAPISpec() ([]byte, error) {
 	json, err := s.OpenAPISpec.MarshalJSON()
@@ -139,44 +264,268 @@ func (s *App) createDefaultErrorResponses() (map[int]*openapi3.Response, error)
 	responses := make(map[int]*openapi3.Response)
 
 	for _, errResponse := range errors.DefaultErrorResponses {
-		responseSchema, ok := s.OpenAPISpec.Components.Schemas["httpGenericError"]
-		if !ok {
-			var err error
+		response, err := s.createErrorResponse(errResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		responses[errResponse.StatusCode()] = response
+	}
 
-			responseSchema, err = generatorNewSchemaRefForValue(new(errors.HTTPError), s.OpenAPISpec.Components.Schemas)
-			if err != nil {
-				return nil, err
-			}
+	return responses, nil
+}
+
+func (s *App) createErrorResponse(errResponse errors.HTTPError) (*openapi3.Response, error) {
+	schemaName := "httpGenericError"
+	schemaValue := any(new(errors.HTTPError))
+	consume := errors.DefaultErrorContentTypeResponses
+
+	if s.problemDetails {
+		schemaName = "problemDetails"
+		schemaValue = new(errors.ProblemDetails)
+		consume = errors.ProblemDetailsContentTypeResponses
+	}
+
+	responseSchema, ok := s.OpenAPISpec.Components.Schemas[schemaName]
+	if !ok {
+		var err error
+
+		responseSchema, err = generatorNewSchemaRefForValue(schemaValue, s.OpenAPISpec.Components.Schemas)
+		if err != nil {
+			return nil, err
+		}
 
-			s.OpenAPISpec.Components.Schemas["httpGenericError"] = responseSchema
+		if s.problemDetails && responseSchema != nil && responseSchema.Value != nil {
+			// problem details may carry any extension member
+			responseSchema.Value.AdditionalProperties = openapi3.AdditionalProperties{Has: openapi3.BoolPtr(true)}
 		}
 
-		response := openapi3.NewResponse().WithDescription(errResponse.Description())
+		s.OpenAPISpec.Components.Schemas[schemaName] = responseSchema
+	}
 
-		var consume []string
-		consume = append(consume, errors.DefaultErrorContentTypeResponses...)
+	response := openapi3.NewResponse().WithDescription(errResponse.Description())
+
+	if responseSchema != nil {
+		content := openapi3.NewContentWithSchemaRef(
+			openapi3.NewSchemaRef(fmt.Sprintf(
+				"#/components/schemas/%s",
+				schemaName,
+			), &openapi3.Schema{}),
+			consume,
+		)
+		response.WithContent(content)
+	}
 
-		if responseSchema != nil {
-			content := openapi3.NewContentWithSchemaRef(
-				openapi3.NewSchemaRef(fmt.Sprintf(
-					"#/components/schemas/%s",
-					"httpGenericError",
-				), &openapi3.Schema{}),
-				consume,
-			)
-			response.WithContent(content)
+	return response, nil
+}
+
+// setup freezes the OpenAPI spec once every route has been registered.
+// The spec is cached, served and saved locally according to the OpenAPIConfig.
+func (s *App) setup() error {
+	s.setupOnce.Do(func() {
+		s.setupErr = s.setupOpenAPI()
+	})
+
+	return s.setupErr
+}
+
+func (s *App) setupOpenAPI() error {
+	jsonSpec, err := s.OpenAPISpec.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	yamlSpec, err := writeOpenAPISpec(jsonSpec)
+	if err != nil {
+		return err
+	}
+
+	s.openAPIJSON = jsonSpec
+	s.openAPIYAML = yamlSpec
+
+	if !s.OpenAPIConfig.DisableLocalSave && s.OpenAPIConfig.YamlURL != "" {
+		err = saveOpenAPISpec(s.OpenAPIConfig.YamlURL, yamlSpec)
+		if err != nil {
+			return err
 		}
+	}
 
-		responses[errResponse.StatusCode()] = response
+	if s.OpenAPIConfig.DisableSwagger {
+		return nil
 	}
 
-	return responses, nil
+	if s.OpenAPIConfig.JSONURL != "" {
+		s.App.Get(s.OpenAPIConfig.JSONURL, func(c *fiber.Ctx) error {
+			c.Type("json")
+
+			return c.Send(s.openAPIJSON)
+		})
+	}
+
+	if s.OpenAPIConfig.YamlSpecURL != "" {
+		s.App.Get(s.OpenAPIConfig.YamlSpecURL, func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderContentType, "application/yaml")
+
+			return c.Send(s.openAPIYAML)
+		})
+	}
+
+	if s.OpenAPIConfig.SwaggerURL != "" && s.OpenAPIConfig.JSONURL != "" {
+		uiHandler := s.OpenAPIConfig.UIHandler
+		if uiHandler == nil {
+			uiHandler = DefaultOpenAPIHandler
+		}
+
+		s.App.Get(s.OpenAPIConfig.SwaggerURL, uiHandler(s.OpenAPIConfig.JSONURL))
+	}
+
+	return nil
+}
+
+// saveOpenAPISpec writes the YAML spec to path, creating the parent directories if needed
+func saveOpenAPISpec(path string, yamlSpec []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, yamlSpec, 0o600)
+}
+
+// OnStart registers hooks called when the App starts, before it serves requests, in registration order.
+// The App does not start when a hook fails.
+func (s *App) OnStart(hooks ...func(ctx context.Context) error) *App {
+	s.startHooks = append(s.startHooks, hooks...)
+
+	return s
+}
+
+// OnShutdown registers hooks called when the App shuts down, once the in-flight requests completed
+// or were cancelled, in reverse registration order, e.g. to close database pools or flush telemetry.
+// The hooks receive the context given to ShutdownWithContext.
+func (s *App) OnShutdown(hooks ...func(ctx context.Context) error) *App {
+	s.shutdownHooks = append(s.shutdownHooks, hooks...)
+
+	return s
+}
+
+// requestsContext returns the context of the requests, cancelled when the App shuts down
+func (s *App) requestsContext() context.Context {
+	s.ctxMu.RLock()
+	defer s.ctxMu.RUnlock()
+
+	return s.ctx
+}
+
+// cancelRequests cancels the context of the requests
+func (s *App) cancelRequests() {
+	s.ctxMu.RLock()
+	defer s.ctxMu.RUnlock()
+
+	s.cancel()
+}
+
+// start freezes the OpenAPI spec, renews the context of the requests when the App was shut down
+// and calls the OnStart hooks
+func (s *App) start(ctx context.Context) error {
+	if err := s.setup(); err != nil {
+		return err
+	}
+
+	s.ctxMu.Lock()
+	if s.ctx.Err() != nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	s.ctxMu.Unlock()
+
+	for _, hook := range s.startHooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+// Listen freezes the OpenAPI spec, calls the OnStart hooks and serves HTTP requests from the given address.
 func (s *App) Listen(address string) error {
+	if err := s.start(context.Background()); err != nil {
+		return err
+	}
+
 	return s.App.Listen(address)
 }
 
+// Listener freezes the OpenAPI spec, calls the OnStart hooks and serves HTTP requests from the given listener.
+func (s *App) Listener(ln net.Listener) error {
+	if err := s.start(context.Background()); err != nil {
+		return err
+	}
+
+	return s.App.Listener(ln)
+}
+
+// Run serves HTTP requests from the given address until ctx is done or the process receives SIGINT or SIGTERM,
+// then shuts the App down, giving ShutdownTimeout to the in-flight requests to complete.
+func (s *App) Run(ctx context.Context, address string) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen(s.Config().Network, address)
+	if err != nil {
+		return err
+	}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- s.App.Listener(ln)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.ShutdownTimeout)
+	defer cancel()
+
+	err = s.ShutdownWithContext(shutdownCtx)
+
+	// the listener may have been registered after the server stopped
+	_ = ln.Close()
+
+	return stderrors.Join(err, <-served)
+}
+
+// Shutdown shuts the App down, waiting for the in-flight requests to complete, see ShutdownWithContext.
 func (s *App) Shutdown() error {
-	return s.App.Shutdown()
+	return s.ShutdownWithContext(context.Background())
+}
+
+// ShutdownWithContext stops accepting connections and waits for the in-flight requests to complete
+// until ctx is done. The context of the requests, see Context.Context, is then cancelled,
+// the open WebSocket connections are closed and the OnShutdown hooks are called.
+// The App may be started again afterwards, with a new context for its requests.
+func (s *App) ShutdownWithContext(ctx context.Context) error {
+	// the requests still in flight at the deadline are cancelled
+	stop := context.AfterFunc(ctx, s.cancelRequests)
+	defer stop()
+
+	err := s.App.ShutdownWithContext(ctx)
+
+	s.cancelRequests()
+
+	errs := []error{err}
+
+	for i := len(s.shutdownHooks) - 1; i >= 0; i-- {
+		errs = append(errs, s.shutdownHooks[i](ctx))
+	}
+
+	return stderrors.Join(errs...)
 }
@@ -1,11 +1,18 @@
 package lite
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
 	"github.com/invopop/yaml"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -189,6 +196,7 @@ func getFreePort() (int, error) {
 
 func TestApp_Listen(t *testing.T) {
 	app := New()
+	app.OpenAPIConfig.YamlURL = filepath.Join(t.TempDir(), "api", "openapi.yaml")
 
 	// Find a free port to avoid conflicts
 	port, err := getFreePort()
@@ -216,4 +224,288 @@ func TestApp_Listen(t *testing.T) {
 
 	// Shutdown the server
 	assert.NoError(t, app.Shutdown())
+
+	// The spec has been saved locally on startup
+	_, err = os.Stat(app.OpenAPIConfig.YamlURL)
+	assert.NoError(t, err)
+}
+
+func TestApp_Setup_ServeOpenAPI(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	Get(app, "/foo", func(c *ContextNoRequest) (string, error) {
+		return "foo", nil
+	})
+
+	assert.NoError(t, app.setup())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/openapi.json", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `"/foo"`)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/openapi.yaml", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "/foo:")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/openapi", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `"\/api\/openapi.json"`)
+	assert.NotContains(t, string(body), "https://")
+}
+
+func TestApp_Setup_SpecIsFrozen(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	assert.NoError(t, app.setup())
+
+	Get(app, "/late", func(c *ContextNoRequest) (string, error) {
+		return "late", nil
+	})
+
+	assert.NoError(t, app.setup())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/openapi.json", nil))
+	assert.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.NotContains(t, string(body), "/late")
+}
+
+func TestApp_Setup_CustomUIHandler(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+	app.OpenAPIConfig.SwaggerURL = "/docs"
+	app.OpenAPIConfig.UIHandler = func(specURL string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			return c.SendString("ui for " + specURL)
+		}
+	}
+
+	assert.NoError(t, app.setup())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/docs", nil))
+	assert.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ui for /api/openapi.json", string(body))
+}
+
+func TestApp_Setup_DisableSwagger(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+	app.OpenAPIConfig.DisableSwagger = true
+
+	assert.NoError(t, app.setup())
+
+	for _, url := range []string{"/openapi", "/api/openapi.json", "/api/openapi.yaml"} {
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode, url)
+	}
+}
+
+func TestApp_Setup_LocalSave(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableSwagger = true
+	app.OpenAPIConfig.YamlURL = filepath.Join(t.TempDir(), "nested", "openapi.yaml")
+
+	assert.NoError(t, app.setup())
+
+	data, err := os.ReadFile(app.OpenAPIConfig.YamlURL)
+	assert.NoError(t, err)
+	assert.Equal(t, app.openAPIYAML, data)
+}
+
+// YamlURL keeps its meaning of local path, the YAML spec being served at YamlSpecURL
+func TestApp_Setup_YamlURL(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.YamlURL = filepath.Join(t.TempDir(), "docs", "openapi.yaml")
+
+	assert.NoError(t, app.setup())
+
+	_, err := os.Stat(app.OpenAPIConfig.YamlURL)
+	assert.NoError(t, err)
+
+	resp, err := app.Test(httptest.NewRequest("GET", app.OpenAPIConfig.YamlURL, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/openapi.yaml", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestApp_Setup_DisableLocalSave(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableSwagger = true
+	app.OpenAPIConfig.DisableLocalSave = true
+	app.OpenAPIConfig.YamlURL = filepath.Join(t.TempDir(), "openapi.yaml")
+
+	assert.NoError(t, app.setup())
+
+	_, err := os.Stat(app.OpenAPIConfig.YamlURL)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestApp_Setup_Error(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	yamlJSONToYAML = mockJSONToYAML
+	defer restoreJSONToYAML()
+
+	assert.Error(t, app.setup())
+	assert.Error(t, app.Listen(":0"))
+}
+
+func TestApp_ShutdownWithContext(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	var hooks []string
+
+	app.OnStart(func(context.Context) error {
+		hooks = append(hooks, "start")
+
+		return nil
+	})
+	app.OnShutdown(
+		func(context.Context) error {
+			hooks = append(hooks, "close database")
+
+			return nil
+		},
+		func(context.Context) error {
+			hooks = append(hooks, "flush telemetry")
+
+			return nil
+		},
+	)
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	Get(app, "/slow", func(_ *ContextNoRequest) (string, error) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+
+		return "done", nil
+	})
+
+	Get(app, "/blocked", func(c *ContextNoRequest) (string, error) {
+		<-c.Context().Done()
+		close(cancelled)
+
+		return "", c.Context().Err()
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	slow := make(chan int)
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		assert.NoError(t, err)
+
+		slow <- resp.StatusCode
+	}()
+
+	go func() {
+		_, _ = http.Get("http://" + ln.Addr().String() + "/blocked")
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	err = app.ShutdownWithContext(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// the slow request completed, the blocked one was cancelled at the deadline
+	assert.Equal(t, http.StatusOK, <-slow)
+	<-cancelled
+
+	assert.Equal(t, []string{"start", "flush telemetry", "close database"}, hooks)
+}
+
+func TestApp_Restart(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	Get(app, "/alive", func(c *ContextNoRequest) (string, error) {
+		return "alive", c.Context().Err()
+	})
+
+	for range 2 {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		served := make(chan error, 1)
+
+		go func() {
+			served <- app.Listener(ln)
+		}()
+
+		resp, err := http.Get("http://" + ln.Addr().String() + "/alive")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		assert.NoError(t, app.Shutdown())
+		assert.NoError(t, <-served)
+	}
+}
+
+func TestApp_OnStart_Error(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	app.OnStart(func(context.Context) error {
+		return errors.New("database unreachable")
+	})
+
+	assert.EqualError(t, app.Listen(":0"), "database unreachable")
+}
+
+func TestApp_Run(t *testing.T) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := false
+
+	app.OnStart(func(context.Context) error {
+		cancel()
+
+		return nil
+	})
+	app.OnShutdown(func(context.Context) error {
+		stopped = true
+
+		return nil
+	})
+
+	assert.NoError(t, app.Run(ctx, "127.0.0.1:0"))
+	assert.True(t, stopped)
 }
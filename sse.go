@@ -0,0 +1,265 @@
+package lite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+// sseKeepAlive is the interval of the comments keeping idle streams open and detecting disconnected clients
+var sseKeepAlive = 15 * time.Second
+
+// SSE registers a GET route streaming Server-Sent Events (text/event-stream) of type Event,
+// documented in the OpenAPI spec with the schema of Event.
+// The request is decoded and validated before the stream starts, so that its errors are written as those of
+// the other routes. handler then sends the events until it returns or the client disconnects,
+// which cancels the context of the stream.
+//
+//	lite.SSE(app, "/jobs/:id/progress", func(stream *lite.EventStream[Progress], req JobRequest) error {
+//		for progress := range jobs.Watch(stream.Context(), req.ID, stream.LastEventID()) {
+//			err := stream.SendMessage(lite.Message[Progress]{ID: progress.ID, Event: "progress", Data: progress})
+//			if err != nil {
+//				return err
+//			}
+//		}
+//
+//		return nil
+//	})
+func SSE[Event, Request any](
+	router Router,
+	path string,
+	handler func(stream *EventStream[Event], req Request) error,
+	middleware ...fiber.Handler,
+) Route[Event, Request] {
+	path = router.options().prefix + path
+	registered := &registeredRoute{}
+
+	route := registerRoute[Event, Request](
+		router,
+		Route[Event, Request]{
+			app:         router.app(),
+			registered:  registered,
+			path:        path,
+			method:      http.MethodGet,
+			contentType: eventStreamContentType,
+			statusCode:  http.StatusOK,
+		},
+		sseHandler(router.app(), handler, path, registered),
+		middleware...,
+	)
+
+	lastEventID := openapi3.NewHeaderParameter(HeaderLastEventID).WithSchema(openapi3.NewStringSchema())
+	lastEventID.Description = "ID of the last event received, to resume the stream after it"
+	route.operation.AddParameter(lastEventID)
+
+	return route
+}
+
+func sseHandler[Event, Request any](
+	app *App,
+	handler func(stream *EventStream[Event], req Request) error,
+	path string,
+	route *registeredRoute,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := route.authenticate(c, app); err != nil {
+			return writeError(c, app, err)
+		}
+
+		ctx := &ContextWithRequest[Request]{
+			ContextNoRequest: ContextNoRequest{ctx: c, app: app, path: path, binder: route.binder},
+		}
+
+		req, err := ctx.Requests()
+		if err != nil {
+			return writeError(c, app, err)
+		}
+
+		// the request context must not be used once the stream has started
+		userContext := c.UserContext()
+		lastEventID := c.Get(HeaderLastEventID)
+
+		c.Status(route.info.StatusCode)
+		c.Set(HeaderContentType, eventStreamContentType)
+		c.Set(HeaderCacheControl, "no-cache")
+		c.Set(HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			stream := newEventStream[Event](userContext, lastEventID, w)
+			defer stream.close()
+
+			if err := handler(stream, req); err != nil && stream.Context().Err() == nil {
+				slog.ErrorContext(stream.Context(), "failed to stream server-sent events", slog.Any("error", err))
+			}
+		})
+
+		return nil
+	}
+}
+
+// Message is a Server-Sent Event with its optional fields
+type Message[Event any] struct {
+	ID    string        // ID of the event, sent back by the client in the Last-Event-ID header when it reconnects
+	Event string        // Name of the event, "message" when empty
+	Retry time.Duration // Reconnection delay of the client, unchanged when zero
+	Data  Event         // Encoded in JSON, unless it is a string or a []byte
+}
+
+// EventStream sends the events of an SSE route. Its methods are safe for concurrent use.
+type EventStream[Event any] struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	lastEventID string
+
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closed bool
+}
+
+func newEventStream[Event any](ctx context.Context, lastEventID string, w *bufio.Writer) *EventStream[Event] {
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream := &EventStream[Event]{
+		ctx:         ctx,
+		cancel:      cancel,
+		lastEventID: lastEventID,
+		w:           w,
+	}
+
+	go stream.keepAlive(sseKeepAlive)
+
+	return stream
+}
+
+// Context returns the context of the stream, canceled when the client disconnects
+func (s *EventStream[Event]) Context() context.Context {
+	return s.ctx
+}
+
+// LastEventID returns the ID of the last event the client received before reconnecting, empty on the first connection
+func (s *EventStream[Event]) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send sends an unnamed event
+func (s *EventStream[Event]) Send(event Event) error {
+	return s.SendMessage(Message[Event]{Data: event})
+}
+
+// SendMessage sends an event with its ID, name and reconnection delay
+func (s *EventStream[Event]) SendMessage(message Message[Event]) error {
+	data, err := encodeEventData(message.Data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if message.ID != "" {
+		writeEventField(&buf, "id", message.ID)
+	}
+
+	if message.Event != "" {
+		writeEventField(&buf, "event", message.Event)
+	}
+
+	if message.Retry > 0 {
+		writeEventField(&buf, "retry", strconv.FormatInt(message.Retry.Milliseconds(), 10))
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		buf.WriteString("data: ")
+		buf.WriteString(strings.TrimSuffix(line, "\r"))
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteByte('\n')
+
+	return s.write(buf.Bytes())
+}
+
+func encodeEventData(data any) ([]byte, error) {
+	switch data := data.(type) {
+	case string:
+		return []byte(data), nil
+	case []byte:
+		return data, nil
+	default:
+		return json.Marshal(data)
+	}
+}
+
+// writeEventField writes a field of an event, whose value cannot span several lines
+func writeEventField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(strings.NewReplacer("\r", "", "\n", "").Replace(value))
+	buf.WriteByte('\n')
+}
+
+// write writes and flushes data to the client. The stream is canceled when the client is gone.
+func (s *EventStream[Event]) write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("event stream closed")
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write(data); err != nil {
+		s.cancel()
+
+		return err
+	}
+
+	if err := s.w.Flush(); err != nil {
+		s.cancel()
+
+		return err
+	}
+
+	return nil
+}
+
+// keepAlive writes a comment on every tick, so that proxies keep the stream open
+// and a disconnected client cancels it even when no event is sent
+func (s *EventStream[Event]) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.write([]byte(": keep-alive\n\n"))
+		}
+	}
+}
+
+// close stops the stream once its handler has returned, the writer being released afterwards
+func (s *EventStream[Event]) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+}
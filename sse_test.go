@@ -0,0 +1,119 @@
+package lite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type progress struct {
+	Percent int `json:"percent"`
+}
+
+type progressRequest struct {
+	ID string `lite:"path=id"`
+}
+
+func TestSSE(t *testing.T) {
+	app := New()
+
+	SSE(app, "/jobs/:id/progress", func(stream *EventStream[progress], req progressRequest) error {
+		if err := stream.Send(progress{Percent: 0}); err != nil {
+			return err
+		}
+
+		err := stream.SendMessage(Message[progress]{
+			ID:    req.ID + "-1",
+			Event: "progress",
+			Retry: 3 * time.Second,
+			Data:  progress{Percent: 50},
+		})
+		if err != nil {
+			return err
+		}
+
+		return stream.SendMessage(Message[progress]{ID: stream.LastEventID() + "+", Event: "done\nevent: forged"})
+	})
+
+	req := httptest.NewRequest("GET", "/jobs/42/progress", nil)
+	req.Header.Set(HeaderLastEventID, "41")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get(HeaderContentType))
+	assert.Equal(t, "no-cache", resp.Header.Get(HeaderCacheControl))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "data: {\"percent\":0}\n\n"+
+		"id: 42-1\nevent: progress\nretry: 3000\ndata: {\"percent\":50}\n\n"+
+		"id: 41+\nevent: doneevent: forged\ndata: {\"percent\":0}\n\n", string(body))
+
+	operation := app.OpenAPISpec.Paths.Find("/jobs/{id}/progress").Get
+	assert.Equal(t, "#/components/schemas/progress", operation.Responses.Value("200").Value.Content["text/event-stream"].Schema.Ref)
+	assert.Equal(t, HeaderLastEventID, operation.Parameters[len(operation.Parameters)-1].Value.Name)
+}
+
+func TestSSE_RequestError(t *testing.T) {
+	app := New()
+
+	type request struct {
+		Limit int `lite:"query=limit" validate:"max=5"`
+	}
+
+	SSE(app, "/events", func(stream *EventStream[string], req request) error {
+		return stream.Send(strings.Repeat("event\n", req.Limit))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events?limit=10", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 422, resp.StatusCode)
+	assert.NotEqual(t, "text/event-stream", resp.Header.Get(HeaderContentType))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/events?limit=2", nil))
+	assert.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "data: event\ndata: event\ndata: \n\n", string(body))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestEventStream_Disconnect(t *testing.T) {
+	stream := newEventStream[string](context.Background(), "", bufio.NewWriter(failingWriter{}))
+	defer stream.close()
+
+	assert.EqualError(t, stream.Send("event"), "broken pipe")
+	assert.ErrorIs(t, stream.Context().Err(), context.Canceled)
+	assert.ErrorIs(t, stream.Send("event"), context.Canceled)
+}
+
+func TestEventStream_KeepAlive(t *testing.T) {
+	defaultKeepAlive := sseKeepAlive
+	sseKeepAlive = time.Millisecond
+
+	defer func() {
+		sseKeepAlive = defaultKeepAlive
+	}()
+
+	stream := newEventStream[string](context.Background(), "", bufio.NewWriter(failingWriter{}))
+	defer stream.close()
+
+	select {
+	case <-stream.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the stream of a disconnected client should be canceled")
+	}
+}
@@ -0,0 +1,282 @@
+package lite
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/valyala/fasthttp"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// streamedBody is implemented by the response bodies written through the body stream of the response,
+// instead of being serialized in memory
+type streamedBody interface {
+	// streamContentType returns the default content type of the routes responding with the body
+	streamContentType() string
+
+	// streamSchema returns the schema of the body documented in the OpenAPI spec
+	streamSchema(s *App) (*openapi3.SchemaRef, error)
+
+	// writeStream writes the body to the response. The body owns release, which releases the context
+	// of the request: it is called once the body has been written, or could not be.
+	writeStream(ctx *fasthttp.RequestCtx, release func()) error
+}
+
+// writeStreamedBody writes a streamed body to the response, releasing the context of the request
+// once it has been written
+func writeStreamedBody(ctx *fasthttp.RequestCtx, body streamedBody, release func()) error {
+	if err := body.writeStream(ctx, release); err != nil {
+		ctx.Error(err.Error(), StatusInternalServerError)
+
+		return err
+	}
+
+	return nil
+}
+
+// releaseReader is the body stream of a response, releasing the context of the request once the server
+// closes it, after writing it or when the client disconnected. The reader is closed when it is an io.Closer.
+type releaseReader struct {
+	io.Reader
+	release func()
+}
+
+func (r releaseReader) Close() error {
+	defer r.release()
+
+	if c, ok := r.Reader.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// isStreamedBody reports whether the response bodies of type T are streamed
+func isStreamedBody[T any]() bool {
+	_, ok := any(*new(T)).(streamedBody)
+
+	return ok
+}
+
+// responseContentType returns the default content type of the routes responding with a T
+func responseContentType[T any]() string {
+	if body, ok := any(*new(T)).(streamedBody); ok {
+		return body.streamContentType()
+	}
+
+	return "application/json"
+}
+
+// Stream is a response body copied from Reader to the client, e.g. an export generated on the fly.
+// Reader is closed once copied when it is an io.Closer.
+type Stream struct {
+	Reader      io.Reader
+	ContentType string // Content type of the response, the content type of the route when empty
+	Size        int64  // Size of the content, sent in chunks when zero
+}
+
+func (Stream) streamContentType() string {
+	return "application/octet-stream"
+}
+
+func (Stream) streamSchema(*App) (*openapi3.SchemaRef, error) {
+	return openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithFormat("binary")), nil
+}
+
+func (s Stream) writeStream(ctx *fasthttp.RequestCtx, release func()) error {
+	if s.ContentType != "" {
+		ctx.SetContentType(s.ContentType)
+	}
+
+	if s.Reader == nil {
+		release()
+
+		return nil
+	}
+
+	size := int(s.Size)
+	if size == 0 {
+		size = -1
+	}
+
+	ctx.SetBodyStream(releaseReader{Reader: s.Reader, release: release}, size)
+
+	return nil
+}
+
+// Seq is an iterator over the items of a streamed response. It stops when yield returns false,
+// and returns the error interrupting the sequence, if any.
+type Seq[T any] func(yield func(T) bool) error
+
+// ChanSeq returns the Seq of the items received from ch until it is closed.
+// Each item is flushed to the client as it is received. The sender should not block forever:
+// the sequence stops at the first item which cannot be written once the client has disconnected.
+func ChanSeq[T any](ch <-chan T) Seq[T] {
+	return func(yield func(T) bool) error {
+		for item := range ch {
+			if !yield(item) {
+				return nil
+			}
+		}
+
+		return nil
+	}
+}
+
+// NDJSON is a response body streaming its items as newline-delimited JSON (application/x-ndjson),
+// documented in the OpenAPI spec with the schema of an item.
+//
+//	lite.Get(app, "/export", func(c *lite.ContextNoRequest) (lite.NDJSON[Item], error) {
+//		return lite.NDJSON[Item](lite.ChanSeq(items)), nil
+//	})
+type NDJSON[T any] Seq[T]
+
+func (NDJSON[T]) streamContentType() string {
+	return ndjsonContentType
+}
+
+func (NDJSON[T]) streamSchema(s *App) (*openapi3.SchemaRef, error) {
+	return itemSchema[T](s)
+}
+
+func (n NDJSON[T]) writeStream(ctx *fasthttp.RequestCtx, release func()) error {
+	writeItems(ctx, Seq[T](n), nil, nil, release, func(w *bufio.Writer, _ int, data []byte) error {
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		return w.WriteByte('\n')
+	})
+
+	return nil
+}
+
+// JSONArray is a response body streaming its items as the elements of a JSON array,
+// documented in the OpenAPI spec as an array of items.
+// The array is left unterminated when the sequence fails, so that clients do not mistake it for a complete one.
+type JSONArray[T any] Seq[T]
+
+func (JSONArray[T]) streamContentType() string {
+	return "application/json"
+}
+
+func (JSONArray[T]) streamSchema(s *App) (*openapi3.SchemaRef, error) {
+	items, err := itemSchema[T](s)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := openapi3.NewArraySchema()
+	schema.Items = items
+
+	return openapi3.NewSchemaRef("", schema), nil
+}
+
+func (a JSONArray[T]) writeStream(ctx *fasthttp.RequestCtx, release func()) error {
+	writeItems(ctx, Seq[T](a), []byte("["), []byte("]"), release, func(w *bufio.Writer, i int, data []byte) error {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+
+		_, err := w.Write(data)
+
+		return err
+	})
+
+	return nil
+}
+
+// itemSchema documents the schema of the items of a streamed response in the components of the spec
+// and returns a reference to it
+func itemSchema[T any](s *App) (*openapi3.SchemaRef, error) {
+	content, err := newResponseContent(s, []string{"application/json"}, new(T), reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	if media := content.Get("application/json"); media != nil {
+		return media.Schema, nil
+	}
+
+	return openapi3.NewSchemaRef("", &openapi3.Schema{}), nil
+}
+
+// writeItems streams the items of seq encoded in JSON, written with writeItem between prefix and suffix.
+// The suffix is only written when the sequence completes. The context of the request is released
+// once the sequence stops.
+func writeItems[T any](
+	ctx *fasthttp.RequestCtx,
+	seq Seq[T],
+	prefix, suffix []byte,
+	release func(),
+	writeItem func(w *bufio.Writer, i int, data []byte) error,
+) {
+	if seq == nil {
+		seq = func(func(T) bool) error { return nil }
+	}
+
+	// the request must not be used once the stream has started
+	path := string(ctx.Path())
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer release()
+
+		if err := streamItems(w, seq, prefix, suffix, writeItem); err != nil {
+			slog.ErrorContext(context.Background(), "failed to stream response",
+				slog.String("path", path), slog.Any("error", err))
+		}
+	})
+}
+
+// streamItems writes the items of seq to w, flushed one by one so that slow sequences reach the client
+// as soon as each item is produced, and stop at the first item the client does not receive
+func streamItems[T any](
+	w *bufio.Writer,
+	seq Seq[T],
+	prefix, suffix []byte,
+	writeItem func(w *bufio.Writer, i int, data []byte) error,
+) error {
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+
+	var (
+		i        int
+		writeErr error
+	)
+
+	err := seq(func(item T) bool {
+		data, err := json.Marshal(item)
+		if err == nil {
+			err = writeItem(w, i, data)
+		}
+
+		if err == nil {
+			err = w.Flush()
+		}
+
+		writeErr = err
+		i++
+
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	_, err = w.Write(suffix)
+
+	return err
+}
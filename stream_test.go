@@ -0,0 +1,166 @@
+package lite
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exportItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestNDJSON(t *testing.T) {
+	app := New()
+
+	Get(app, "/export", func(_ *ContextNoRequest) (NDJSON[exportItem], error) {
+		items := make(chan exportItem, 2)
+		items <- exportItem{ID: 1, Name: "first"}
+		items <- exportItem{ID: 2, Name: "second"}
+		close(items)
+
+		return NDJSON[exportItem](ChanSeq(items)), nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/export", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get(HeaderContentType))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "{\"id\":1,\"name\":\"first\"}\n{\"id\":2,\"name\":\"second\"}\n", string(body))
+
+	content := app.OpenAPISpec.Paths.Find("/export").Get.Responses.Value("200").Value.Content
+	assert.Equal(t, "#/components/schemas/exportItem", content["application/x-ndjson"].Schema.Ref)
+	assert.NotNil(t, app.OpenAPISpec.Components.Schemas["exportItem"])
+	assert.True(t, app.Routes()[0].Streamed)
+}
+
+func TestStreamItems_Flush(t *testing.T) {
+	var (
+		out     strings.Builder
+		flushed []string
+	)
+
+	w := bufio.NewWriter(&out)
+
+	seq := Seq[exportItem](func(yield func(exportItem) bool) error {
+		for i := 1; i <= 2; i++ {
+			if !yield(exportItem{ID: i}) {
+				return nil
+			}
+
+			flushed = append(flushed, out.String())
+		}
+
+		return nil
+	})
+
+	err := streamItems(w, seq, []byte("["), []byte("]"), func(w *bufio.Writer, _ int, data []byte) error {
+		_, err := w.Write(data)
+
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`[{"id":1,"name":""}`, `[{"id":1,"name":""}{"id":2,"name":""}`}, flushed)
+}
+
+func TestStreamItems_Disconnect(t *testing.T) {
+	items := make(chan exportItem, 3)
+	for i := 1; i <= 3; i++ {
+		items <- exportItem{ID: i}
+	}
+	close(items)
+
+	writeItem := func(w *bufio.Writer, _ int, data []byte) error {
+		_, err := w.Write(data)
+
+		return err
+	}
+
+	err := streamItems(bufio.NewWriter(failingWriter{}), ChanSeq(items), nil, nil, writeItem)
+	assert.EqualError(t, err, "broken pipe")
+	assert.Len(t, items, 2)
+}
+
+func TestJSONArray(t *testing.T) {
+	app := New()
+
+	type exportRequest struct {
+		Fail bool `lite:"query=fail"`
+	}
+
+	Get(app, "/export", func(c *ContextWithRequest[exportRequest]) (JSONArray[exportItem], error) {
+		req, err := c.Requests()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(yield func(exportItem) bool) error {
+			for i := 1; i <= 3; i++ {
+				if req.Fail && i == 3 {
+					return errors.New("database gone")
+				}
+
+				if !yield(exportItem{ID: i}) {
+					return nil
+				}
+			}
+
+			return nil
+		}, nil
+	})
+
+	Get(app, "/empty", func(_ *ContextNoRequest) (JSONArray[exportItem], error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{path: "/export", body: `[{"id":1,"name":""},{"id":2,"name":""},{"id":3,"name":""}]`},
+		{path: "/export?fail=true", body: `[{"id":1,"name":""},{"id":2,"name":""}`},
+		{path: "/empty", body: `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			assert.NoError(t, err)
+
+			assert.Equal(t, "application/json", resp.Header.Get(HeaderContentType))
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+
+	schema := app.OpenAPISpec.Paths.Find("/export").Get.Responses.Value("200").Value.Content["application/json"].Schema
+	assert.True(t, schema.Value.Type.Is("array"))
+	assert.Equal(t, "#/components/schemas/exportItem", schema.Value.Items.Ref)
+}
+
+func TestStream(t *testing.T) {
+	app := New()
+
+	Get(app, "/report", func(_ *ContextNoRequest) (Stream, error) {
+		return Stream{Reader: strings.NewReader("id,name\n1,first\n"), ContentType: "text/csv"}, nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/report", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get(HeaderContentType))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "id,name\n1,first\n", string(body))
+
+	content := app.OpenAPISpec.Paths.Find("/report").Get.Responses.Value("200").Value.Content
+	assert.Equal(t, "binary", content["application/octet-stream"].Schema.Value.Format)
+}
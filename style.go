@@ -0,0 +1,112 @@
+package lite
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Array parameters are serialized as described by the OpenAPI style and explode options,
+// declared in the lite tag of the field:
+//
+//	IDs    []int    `lite:"query=ids"`                         // ?ids=1&ids=2
+//	Status []string `lite:"query=status,explode=false"`        // ?status=a,b
+//	Tags   []string `lite:"query=tags,style=pipeDelimited"`    // ?tags=a|b
+//	Langs  []string `lite:"header=Accept-Lang"`                // Accept-Lang: en, fr
+//
+// Query and cookie parameters default to the form style and are exploded (repeated keys),
+// path and header parameters default to the simple style (comma-separated values).
+// Repeated keys and headers are always accepted, their values are split when the parameter is not exploded.
+const (
+	styleForm           = "form"
+	styleSimple         = "simple"
+	styleSpaceDelimited = "spaceDelimited"
+	stylePipeDelimited  = "pipeDelimited"
+)
+
+type paramStyle struct {
+	style   string
+	explode bool
+}
+
+// newParamStyle returns the style of a parameter from its location (path, query, header or cookie) and its tag
+func newParamStyle(in string, tagMap map[string]string) (paramStyle, error) {
+	style := paramStyle{style: styleForm, explode: true}
+
+	if in == "path" || in == "header" {
+		style = paramStyle{style: styleSimple}
+	}
+
+	if value, ok := tagMap["style"]; ok {
+		style.style = value
+		style.explode = value == styleForm
+	}
+
+	switch {
+	case in == "path" || in == "header":
+		if style.style != styleSimple {
+			return style, fmt.Errorf("style %s is not supported in %s, only %s is", style.style, in, styleSimple)
+		}
+	case style.style == styleForm:
+	case in == "query" && (style.style == styleSpaceDelimited || style.style == stylePipeDelimited):
+	default:
+		return style, fmt.Errorf("style %s is not supported in %s", style.style, in)
+	}
+
+	if value, ok := tagMap["explode"]; ok {
+		explode, err := strconv.ParseBool(value)
+		if err != nil {
+			return style, fmt.Errorf("explode expects a boolean, got %q", value)
+		}
+
+		style.explode = explode
+	}
+
+	return style, nil
+}
+
+// delimiter returns the separator of the values of an array parameter, or "" when they are repeated
+func (s paramStyle) delimiter() string {
+	switch {
+	case s.style == styleSimple:
+		return ","
+	case s.explode:
+		return ""
+	case s.style == styleSpaceDelimited:
+		return " "
+	case s.style == stylePipeDelimited:
+		return "|"
+	default:
+		return ","
+	}
+}
+
+// split returns the values of an array parameter
+func (s paramStyle) split(values []string) []string {
+	delimiter := s.delimiter()
+	if delimiter == "" {
+		return values
+	}
+
+	var split []string
+
+	for _, value := range values {
+		for _, v := range strings.Split(value, delimiter) {
+			if s.style == styleSimple {
+				v = strings.TrimSpace(v)
+			}
+
+			split = append(split, v)
+		}
+	}
+
+	return split
+}
+
+// isArrayParam reports whether a parameter holds several values: a slice or an array, but not of bytes
+func isArrayParam(t reflect.Type) bool {
+	t = indirectType(t)
+
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
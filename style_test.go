@@ -0,0 +1,194 @@
+package lite
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewParamStyle(t *testing.T) {
+	tests := []struct {
+		in        string
+		tag       string
+		delimiter string
+		style     paramStyle
+	}{
+		{"query", "query=ids", "", paramStyle{style: styleForm, explode: true}},
+		{"query", "query=ids,explode=false", ",", paramStyle{style: styleForm}},
+		{"query", "query=ids,style=spaceDelimited", " ", paramStyle{style: styleSpaceDelimited}},
+		{"query", "query=ids,style=pipeDelimited", "|", paramStyle{style: stylePipeDelimited}},
+		{"query", "query=ids,style=pipeDelimited,explode=true", "", paramStyle{style: stylePipeDelimited, explode: true}},
+		{"header", "header=ids", ",", paramStyle{style: styleSimple}},
+		{"path", "path=ids,explode=true", ",", paramStyle{style: styleSimple, explode: true}},
+		{"cookie", "cookie=ids,explode=false", ",", paramStyle{style: styleForm}},
+	}
+
+	for _, test := range tests {
+		style, err := newParamStyle(test.in, parseTag(test.tag))
+		assert.NoError(t, err, test.tag)
+		assert.Equal(t, test.style, style, test.tag)
+		assert.Equal(t, test.delimiter, style.delimiter(), test.tag)
+	}
+}
+
+func TestNewParamStyle_Error(t *testing.T) {
+	tests := []struct {
+		in  string
+		tag string
+	}{
+		{"query", "query=ids,style=simple"},
+		{"query", "query=ids,style=deepObject"},
+		{"header", "header=ids,style=form"},
+		{"cookie", "cookie=ids,style=pipeDelimited"},
+		{"query", "query=ids,explode=maybe"},
+	}
+
+	for _, test := range tests {
+		_, err := newParamStyle(test.in, parseTag(test.tag))
+		assert.Error(t, err, test.tag)
+	}
+}
+
+func TestParamStyle_Split(t *testing.T) {
+	assert.Equal(t, []string{"a", "b,c"}, paramStyle{style: styleForm, explode: true}.split([]string{"a", "b,c"}))
+	assert.Equal(t, []string{"a", "b", "c"}, paramStyle{style: styleForm}.split([]string{"a", "b,c"}))
+	assert.Equal(t, []string{"a", "b"}, paramStyle{style: styleSimple}.split([]string{"a, b"}))
+	assert.Equal(t, []string{"a", "b"}, paramStyle{style: stylePipeDelimited}.split([]string{"a|b"}))
+}
+
+type arrayParamsForm struct {
+	Tags []string `form:"tags"`
+	Name string   `form:"name"`
+}
+
+type arrayParamsRequest struct {
+	IDs    []int           `lite:"path=ids"`
+	Status []string        `lite:"query=status"`
+	Limits []uint          `lite:"query=limits,explode=false"`
+	Scores *[]float64      `lite:"query=scores,style=pipeDelimited"`
+	Flags  [2]bool         `lite:"query=flags,style=spaceDelimited"`
+	Langs  []string        `lite:"header=Accept-Lang"`
+	Body   arrayParamsForm `lite:"req=body,application/x-www-form-urlencoded"`
+}
+
+func newArrayParamsApp() *App {
+	app := New()
+
+	Post(app, "/items/:ids", func(c *ContextWithRequest[arrayParamsRequest]) (arrayParamsRequest, error) {
+		return c.Requests()
+	})
+
+	return app
+}
+
+func TestArrayParams(t *testing.T) {
+	app := newArrayParamsApp()
+
+	form := url.Values{"tags": {"a", "b"}, "name": {"john", "jane"}}
+	query := "?status=a&status=b&limits=1,2&limits=3&scores=1.5|2&flags=true%20false"
+
+	req := httptest.NewRequest("POST", "/items/1,2"+query, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept-Lang", "en, fr")
+	req.Header.Add("Accept-Lang", "de")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var params arrayParamsRequest
+
+	assert.NoError(t, json.Unmarshal(data, &params))
+	assert.Equal(t, []int{1, 2}, params.IDs)
+	assert.Equal(t, []string{"a", "b"}, params.Status)
+	assert.Equal(t, []uint{1, 2, 3}, params.Limits)
+	assert.Equal(t, &[]float64{1.5, 2}, params.Scores)
+	assert.Equal(t, [2]bool{true, false}, params.Flags)
+	assert.Equal(t, []string{"en", "fr", "de"}, params.Langs)
+	assert.Equal(t, arrayParamsForm{Tags: []string{"a", "b"}, Name: "john"}, params.Body)
+}
+
+func TestArrayParams_Error(t *testing.T) {
+	app := newArrayParamsApp()
+
+	for _, target := range []string{"/items/1,a", "/items/1?limits=1,-2", "/items/1?flags=true%20false%20true"} {
+		req := httptest.NewRequest("POST", target, strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode, target)
+	}
+}
+
+func TestArrayParams_OpenAPI(t *testing.T) {
+	app := newArrayParamsApp()
+
+	parameters := app.OpenAPISpec.Paths.Find("/items/{ids}").Post.Parameters
+
+	tests := []struct {
+		in      string
+		name    string
+		style   string
+		explode bool
+	}{
+		{"path", "ids", styleSimple, false},
+		{"query", "status", styleForm, true},
+		{"query", "limits", styleForm, false},
+		{"query", "scores", stylePipeDelimited, false},
+		{"query", "flags", styleSpaceDelimited, false},
+		{"header", "Accept-Lang", styleSimple, false},
+	}
+
+	for _, test := range tests {
+		parameter := parameters.GetByInAndName(test.in, test.name)
+
+		assert.Equal(t, test.style, parameter.Style, test.name)
+		assert.Equal(t, test.explode, *parameter.Explode, test.name)
+	}
+
+	assert.False(t, parameters.GetByInAndName("query", "status").Required)
+	assert.True(t, parameters.GetByInAndName("header", "Accept-Lang").Schema.Value.Type.Is("array"))
+	assert.Equal(t, uint64(2), *parameters.GetByInAndName("query", "flags").Schema.Value.MaxItems)
+}
+
+type invalidStyleRequest struct {
+	IDs []int `lite:"header=ids,style=form"`
+}
+
+func TestArrayParams_InvalidStyle(t *testing.T) {
+	app := New()
+
+	assert.Panics(t, func() {
+		Get(app, "/foo", func(c *ContextWithRequest[invalidStyleRequest]) (string, error) {
+			return "", nil
+		})
+	})
+}
+
+type otherArrayParamsRequest struct {
+	Status []string `lite:"query=status,explode=false" validate:"required"`
+}
+
+func TestArrayParams_OpenAPI_SharedParamName(t *testing.T) {
+	app := newArrayParamsApp()
+
+	Get(app, "/others", func(c *ContextWithRequest[otherArrayParamsRequest]) (string, error) {
+		return "", nil
+	})
+
+	items := app.OpenAPISpec.Paths.Find("/items/{ids}").Post.Parameters.GetByInAndName("query", "status")
+	others := app.OpenAPISpec.Paths.Find("/others").Get.Parameters.GetByInAndName("query", "status")
+
+	assert.True(t, *items.Explode)
+	assert.False(t, items.Required)
+	assert.False(t, *others.Explode)
+	assert.True(t, others.Required)
+}
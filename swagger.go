@@ -0,0 +1,31 @@
+package lite
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed ui/index.html
+var openAPIUIPage string
+
+var openAPIUITemplate = template.Must(template.New("openapi-ui").Parse(openAPIUIPage))
+
+// DefaultOpenAPIHandler serves the built-in OpenAPI UI for the spec at specURL.
+// The page is self-contained and does not load any external asset, so it also works offline.
+func DefaultOpenAPIHandler(specURL string) fiber.Handler {
+	var page bytes.Buffer
+
+	err := openAPIUITemplate.Execute(&page, struct{ SpecURL string }{SpecURL: specURL})
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Type("html", "utf-8")
+
+		return c.Send(page.Bytes())
+	}
+}
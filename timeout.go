@@ -0,0 +1,116 @@
+package lite
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errHandlerTimeout is the cause of the cancellation of the context of a request exceeding the timeout of its route
+	errHandlerTimeout = stderrors.New("handler timeout")
+
+	// errClientDisconnected is the cause of the cancellation of the context of a request whose client disconnected
+	errClientDisconnected = stderrors.New("client disconnected")
+)
+
+// requestContext derives the context of a request handled by a route, see Context.Context.
+// It is cancelled when the client disconnects, and when the timeout of the route passes.
+// Watching the connection costs a goroutine and a few allocations per request, see BenchmarkHandler_KeepAlive.
+// The returned function releases the context, once the handler returned or, for a streamed response,
+// once the stream has been written.
+func (r *registeredRoute) requestContext(c *fiber.Ctx) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(c.UserContext())
+
+	stopTimeout := context.CancelFunc(func() {})
+	if r.timeout > 0 {
+		ctx, stopTimeout = context.WithTimeoutCause(ctx, r.timeout, errHandlerTimeout)
+	}
+
+	stopWatching := watchDisconnect(c.Context().Conn(), func() {
+		cancel(errClientDisconnected)
+	})
+
+	return ctx, func() {
+		stopWatching()
+		stopTimeout()
+		cancel(context.Canceled)
+	}
+}
+
+// requestScope holds the context of a request handled by a route, derived before its middleware run
+type requestScope struct {
+	ctx      context.Context
+	release  func()
+	streamed bool // whether a streamed response took the release of the context over
+}
+
+var requestScopeKey = NewKey[*requestScope]("lite.scope")
+
+// withRequestContext derives the context of the requests of the route before its middleware run, so that they
+// are cancelled with the handler, and releases it once the handler returned, unless the response is streamed
+func (r *registeredRoute) withRequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, release := r.requestContext(c)
+		c.SetUserContext(ctx)
+
+		scope := &requestScope{ctx: ctx, release: release}
+		c.Context().SetUserValue(requestScopeKey, scope)
+
+		defer func() {
+			if !scope.streamed {
+				release()
+			}
+		}()
+
+		return c.Next()
+	}
+}
+
+// scopeOf returns the scope of a request, or the user context with nothing to release when withRequestContext
+// did not run for the route
+func scopeOf(c *fiber.Ctx) *requestScope {
+	if scope, ok := c.Context().UserValue(requestScopeKey).(*requestScope); ok {
+		return scope
+	}
+
+	return &requestScope{ctx: c.UserContext(), release: func() {}}
+}
+
+// timeoutError returns a 503 Service Unavailable error once the context of a request passed the timeout
+// of its route, whatever the handler returned: a response completed after the timeout is not sent
+func timeoutError(ctx context.Context, err error) error {
+	if !stderrors.Is(context.Cause(ctx), errHandlerTimeout) {
+		return err
+	}
+
+	return errors.NewError(http.StatusServiceUnavailable, "the request timed out")
+}
+
+// documentTimeout documents the timeout of a route and its 503 Service Unavailable response
+func documentTimeout(s *App, operation *openapi3.Operation, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	response, err := s.createErrorResponse(errors.NewError(http.StatusServiceUnavailable))
+	if err != nil {
+		return err
+	}
+
+	response.WithDescription("Service Unavailable: the request did not complete within " + timeout.String())
+	operation.AddResponse(http.StatusServiceUnavailable, response)
+
+	if operation.Extensions == nil {
+		operation.Extensions = make(map[string]any)
+	}
+
+	operation.Extensions["x-timeout"] = timeout.String()
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package lite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	app := New()
+
+	slow := app.Group("/slow").Timeout(20 * time.Millisecond)
+
+	Get(slow, "/blocked", func(c *ContextNoRequest) (string, error) {
+		<-c.Context().Done()
+
+		return "", fmt.Errorf("query aborted: %w", c.Context().Err())
+	})
+
+	Get(slow, "/late", func(_ *ContextNoRequest) (string, error) {
+		time.Sleep(40 * time.Millisecond)
+
+		return "done", nil
+	})
+
+	Get(slow, "/fast", func(c *ContextNoRequest) (string, error) {
+		deadline, ok := c.Context().Deadline()
+		if !ok || time.Until(deadline) > time.Second {
+			return "", fmt.Errorf("unexpected deadline %v", deadline)
+		}
+
+		return "done", nil
+	}).Timeout(time.Minute)
+
+	Get(app, "/unbounded", func(c *ContextNoRequest) (string, error) {
+		if _, ok := c.Context().Deadline(); ok {
+			return "", fmt.Errorf("unexpected deadline")
+		}
+
+		return "done", nil
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/slow/blocked", status: 503},
+		{path: "/slow/late", status: 503},
+		{path: "/slow/fast", status: 500},
+		{path: "/unbounded", status: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			require.NoError(t, err)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.status, resp.StatusCode, string(body))
+		})
+	}
+
+	blocked := app.OpenAPISpec.Paths.Find("/slow/blocked").Get
+	assert.Equal(t, "20ms", blocked.Extensions["x-timeout"])
+	assert.Contains(t, *blocked.Responses.Value("503").Value.Description, "20ms")
+
+	fast := app.OpenAPISpec.Paths.Find("/slow/fast").Get
+	assert.Equal(t, "1m0s", fast.Extensions["x-timeout"])
+
+	assert.Nil(t, app.OpenAPISpec.Paths.Find("/unbounded").Get.Responses.Value("503"))
+}
+
+// the middleware of a route run within the context of its requests
+func TestTimeout_Middleware(t *testing.T) {
+	app := New()
+
+	deadline := NewMiddleware(func(c *ContextWithRequest[struct{}]) error {
+		if _, ok := c.Context().Deadline(); !ok {
+			return fmt.Errorf("no deadline in middleware")
+		}
+
+		return c.Next()
+	})
+
+	slow := app.Group("/slow").Timeout(20 * time.Millisecond).UseMiddleware(deadline)
+
+	Get(slow, "/items", func(_ *ContextNoRequest) (string, error) {
+		return "done", nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/slow/items", nil))
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode, string(body))
+}
+
+// the context of a streamed response lives until the stream is written, within the timeout of its route
+func TestTimeout_Stream(t *testing.T) {
+	app := New()
+
+	Get(app, "/export", func(c *ContextNoRequest) (NDJSON[exportItem], error) {
+		ctx := c.Context()
+
+		return func(yield func(exportItem) bool) error {
+			for i := 1; i <= 3; i++ {
+				time.Sleep(5 * time.Millisecond)
+
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				if !yield(exportItem{ID: i}) {
+					return nil
+				}
+			}
+
+			return nil
+		}, nil
+	}).Timeout(time.Minute)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/export", nil))
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "{\"id\":1,\"name\":\"\"}\n{\"id\":2,\"name\":\"\"}\n{\"id\":3,\"name\":\"\"}\n", string(body))
+}
+
+func TestClientDisconnect(t *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	cause := make(chan error, 1)
+
+	Get(app, "/reports", func(c *ContextNoRequest) (string, error) {
+		close(started)
+
+		select {
+		case <-c.Context().Done():
+			cause <- context.Cause(c.Context())
+		case <-time.After(5 * time.Second):
+			cause <- nil
+		}
+
+		return "", c.Context().Err()
+	})
+
+	Get(app, "/items", func(_ *ContextNoRequest) (string, error) {
+		return "item", nil
+	})
+
+	addr := serveWebSocket(t, app)
+
+	// keep-alive requests are served on the same connection once the handler returned
+	client := &http.Client{Transport: &http.Transport{MaxConnsPerHost: 1}}
+
+	for range 3 {
+		resp, err := client.Get("http://" + addr + "/items")
+		require.NoError(t, err)
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("GET /reports HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, conn.Close())
+
+	assert.ErrorIs(t, <-cause, errClientDisconnected)
+}
+
+// BenchmarkHandler_KeepAlive serves requests on a keep-alive TCP connection,
+// on which every request is watched for the disconnection of the client
+func BenchmarkHandler_KeepAlive(b *testing.B) {
+	app := New()
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	Get(app, "/items", func(_ *ContextNoRequest) (string, error) {
+		return "item", nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	defer func() {
+		_ = app.Shutdown()
+	}()
+
+	client := &http.Client{Transport: &http.Transport{MaxConnsPerHost: 1}}
+	url := "http://" + ln.Addr().String() + "/items"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+}
@@ -0,0 +1,751 @@
+package lite
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	liteErrors "github.com/go-lite/lite/errors"
+	"github.com/google/uuid"
+)
+
+// Validation rules are declared with the `validate` struct tag on request fields (path, query, header, cookie)
+// and on the fields of request bodies:
+//
+//	Limit  int      `lite:"query=limit"  validate:"min=1,max=100"`
+//	Status []string `lite:"query=status" validate:"enum=active|archived"`
+//	Name   string   `json:"name"         validate:"required,max=64,pattern=^[a-z]+$"`
+//
+// Supported rules:
+//   - required: the value must not be the zero value (nor a nil pointer)
+//   - min, max: bounds of a number, or of the length of a string, slice or map
+//   - len: exact length of a string, slice or map
+//   - enum: allowed values separated by "|"
+//   - pattern: regular expression a string must match. It must be the last rule of the tag
+//   - email, uuid, url: format of a string
+//
+// The rules of parameters absent from the request and of nil pointers are skipped, unless they are required.
+// The zero values sent explicitly, such as ?limit=0, are validated.
+const validateTagName = "validate"
+
+const (
+	ruleRequired = "required"
+	ruleMin      = "min"
+	ruleMax      = "max"
+	ruleLen      = "len"
+	ruleEnum     = "enum"
+	rulePattern  = "pattern"
+	ruleEmail    = "email"
+	ruleUUID     = "uuid"
+	ruleURL      = "url"
+)
+
+type validationRule struct {
+	name    string
+	value   string
+	number  float64
+	pattern *regexp.Regexp
+	enum    []string
+}
+
+type validationCacheKey struct {
+	fieldType reflect.Type
+	tag       string
+}
+
+var validationRulesCache sync.Map
+
+// validationRules returns the compiled validation rules of a struct field
+func validationRules(field reflect.StructField) ([]validationRule, error) {
+	tag, ok := field.Tag.Lookup(validateTagName)
+	if !ok || tag == "" {
+		return nil, nil
+	}
+
+	key := validationCacheKey{fieldType: field.Type, tag: tag}
+
+	if rules, ok := validationRulesCache.Load(key); ok {
+		return rules.([]validationRule), nil
+	}
+
+	rules, err := parseValidationTag(field.Type, tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation rules for field %s: %w", field.Name, err)
+	}
+
+	validationRulesCache.Store(key, rules)
+
+	return rules, nil
+}
+
+func parseValidationTag(fieldType reflect.Type, tag string) ([]validationRule, error) {
+	var rules []validationRule
+
+	for tag != "" {
+		var part string
+
+		// the pattern rule is the last one so that the regular expression may contain commas
+		if strings.HasPrefix(tag, rulePattern+"=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		rule, err := newValidationRule(fieldType, name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func newValidationRule(fieldType reflect.Type, name, value string) (validationRule, error) {
+	rule := validationRule{name: name, value: value}
+	kind := indirectType(fieldType).Kind()
+
+	switch name {
+	case ruleRequired:
+	case ruleMin, ruleMax, ruleLen:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return rule, fmt.Errorf("rule %s expects a number, got %q", name, value)
+		}
+
+		if !isNumberKind(kind) && !hasLength(kind) || name == ruleLen && !hasLength(kind) {
+			return rule, fmt.Errorf("rule %s is not supported on %s", name, kind)
+		}
+
+		rule.number = number
+	case ruleEnum:
+		if value == "" {
+			return rule, fmt.Errorf("rule %s expects values separated by |", name)
+		}
+
+		rule.enum = strings.Split(value, "|")
+	case rulePattern:
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return rule, fmt.Errorf("rule %s: %w", name, err)
+		}
+
+		if kind != reflect.String {
+			return rule, fmt.Errorf("rule %s is not supported on %s", name, kind)
+		}
+
+		rule.pattern = pattern
+	case ruleEmail, ruleUUID, ruleURL:
+		if kind != reflect.String {
+			return rule, fmt.Errorf("rule %s is not supported on %s", name, kind)
+		}
+	default:
+		return rule, fmt.Errorf("unknown rule %q", name)
+	}
+
+	return rule, nil
+}
+
+// checkRules returns a message for each rule the value breaks. Absent values, and nil pointers,
+// only break the required rule.
+func checkRules(val reflect.Value, present bool, rules []validationRule) []string {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	required := hasRule(rules, ruleRequired)
+
+	for present && val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			present = false
+
+			break
+		}
+
+		val = val.Elem()
+	}
+
+	if !present || required && val.IsZero() {
+		if required {
+			return []string{"is required"}
+		}
+
+		return nil
+	}
+
+	var messages []string
+
+	for _, rule := range rules {
+		if message := rule.check(val); message != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+func (r validationRule) check(val reflect.Value) string {
+	switch r.name {
+	case ruleMin:
+		if isNumberKind(val.Kind()) && numberOf(val) < r.number {
+			return fmt.Sprintf("must be greater than or equal to %s", r.value)
+		}
+
+		if hasLength(val.Kind()) && float64(lengthOf(val)) < r.number {
+			return fmt.Sprintf("length must be at least %s", r.value)
+		}
+	case ruleMax:
+		if isNumberKind(val.Kind()) && numberOf(val) > r.number {
+			return fmt.Sprintf("must be less than or equal to %s", r.value)
+		}
+
+		if hasLength(val.Kind()) && float64(lengthOf(val)) > r.number {
+			return fmt.Sprintf("length must be at most %s", r.value)
+		}
+	case ruleLen:
+		if float64(lengthOf(val)) != r.number {
+			return fmt.Sprintf("length must be %s", r.value)
+		}
+	case ruleEnum:
+		return r.checkEnum(val)
+	case rulePattern:
+		if !r.pattern.MatchString(val.String()) {
+			return fmt.Sprintf("must match pattern %s", r.value)
+		}
+	case ruleEmail:
+		address, err := mail.ParseAddress(val.String())
+		if err != nil || address.Address != val.String() {
+			return "must be a valid email address"
+		}
+	case ruleUUID:
+		if _, err := uuid.Parse(val.String()); err != nil {
+			return "must be a valid UUID"
+		}
+	case ruleURL:
+		u, err := url.ParseRequestURI(val.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL"
+		}
+	}
+
+	return ""
+}
+
+func (r validationRule) checkEnum(val reflect.Value) string {
+	values := []reflect.Value{val}
+
+	if val.Kind() == reflect.Slice || val.Kind() == reflect.Array {
+		values = values[:0]
+
+		for i := 0; i < val.Len(); i++ {
+			values = append(values, reflect.Indirect(val.Index(i)))
+		}
+	}
+
+	for _, v := range values {
+		found := false
+
+		for _, allowed := range r.enum {
+			if fmt.Sprint(v.Interface()) == allowed {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Sprintf("must be one of [%s]", strings.Join(r.enum, ", "))
+		}
+	}
+
+	return ""
+}
+
+// validate checks every parameter and body field bound by the binder against its validation rules.
+// All the invalid fields are reported in a single Unprocessable Entity HTTPError.
+// The parameters missing from bound are absent from the request.
+func (b *binder) validate(dstVal reflect.Value, bound boundParams) error {
+	var fieldErrors []liteErrors.FieldError
+
+	for _, field := range b.fields {
+		if len(field.rules) == 0 && field.body == nil {
+			continue
+		}
+
+		fieldVal := dstVal.FieldByIndex(field.index)
+		present := field.in == "body" || bound.has(field.in, field.name)
+
+		for _, message := range checkRules(fieldVal, present, field.rules) {
+			fieldErrors = append(fieldErrors, liteErrors.FieldError{Field: field.name, In: field.in, Message: message})
+		}
+
+		if field.body != nil {
+			field.body.validate(fieldVal, "", &fieldErrors)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return liteErrors.NewValidationError(fieldErrors...)
+	}
+
+	return nil
+}
+
+// bodyValidator validates the values of a body type, compiled once from the validation rules of its fields
+type bodyValidator struct {
+	fields []bodyFieldValidator // fields of a struct
+	elem   *bodyValidator       // elements of a slice, an array or a map
+}
+
+type bodyFieldValidator struct {
+	index     int
+	name      string // name of the field in the body, empty for the embedded structs
+	rules     []validationRule
+	validator *bodyValidator
+}
+
+// newBodyValidator compiles the validator of a body type, nil when none of its fields declares validation rules.
+// The validators being compiled are shared through compiled, so that recursive types are supported.
+func newBodyValidator(t reflect.Type, structTag string, compiled map[reflect.Type]*bodyValidator) (*bodyValidator, error) {
+	t = indirectType(t)
+
+	if v, ok := compiled[t]; ok {
+		return v, nil
+	}
+
+	if !hasValidationRules(t) {
+		return nil, nil
+	}
+
+	v := &bodyValidator{}
+	compiled[t] = v
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			rules, err := validationRules(field)
+			if err != nil {
+				return nil, err
+			}
+
+			validator, err := newBodyValidator(field.Type, structTag, compiled)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(rules) == 0 && validator == nil {
+				continue
+			}
+
+			name := fieldNameFromTags(field, structTag)
+			if field.Anonymous && name == field.Name {
+				name = ""
+			}
+
+			v.fields = append(v.fields, bodyFieldValidator{index: i, name: name, rules: rules, validator: validator})
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		elem, err := newBodyValidator(t.Elem(), structTag, compiled)
+		if err != nil {
+			return nil, err
+		}
+
+		v.elem = elem
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.Interface,
+		reflect.Ptr, reflect.String, reflect.UnsafePointer:
+		fallthrough
+	default:
+	}
+
+	return v, nil
+}
+
+func (v *bodyValidator) validate(val reflect.Value, path string, fieldErrors *[]liteErrors.FieldError) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		for _, field := range v.fields {
+			fieldVal := val.Field(field.index)
+
+			fieldPath := path
+			if field.name != "" {
+				fieldPath = joinFieldPath(path, field.name)
+			}
+
+			for _, message := range checkRules(fieldVal, true, field.rules) {
+				*fieldErrors = append(*fieldErrors, liteErrors.FieldError{Field: fieldPath, In: "body", Message: message})
+			}
+
+			if field.validator != nil {
+				field.validator.validate(fieldVal, fieldPath, fieldErrors)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.elem == nil {
+			return
+		}
+
+		for i := 0; i < val.Len(); i++ {
+			v.elem.validate(val.Index(i), fmt.Sprintf("%s[%d]", path, i), fieldErrors)
+		}
+	case reflect.Map:
+		if v.elem == nil {
+			return
+		}
+
+		iter := val.MapRange()
+		for iter.Next() {
+			v.elem.validate(iter.Value(), joinFieldPath(path, fmt.Sprint(iter.Key())), fieldErrors)
+		}
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.Interface,
+		reflect.Ptr, reflect.String, reflect.UnsafePointer:
+		fallthrough
+	default:
+	}
+}
+
+// hasValidationRules reports whether a request type declares validation rules on any of its fields
+func hasValidationRules(t reflect.Type) bool {
+	return hasValidationRulesVisited(t, map[reflect.Type]bool{})
+}
+
+func hasValidationRulesVisited(t reflect.Type, visited map[reflect.Type]bool) bool {
+	t = indirectType(t)
+
+	if visited[t] {
+		return false
+	}
+
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			if field.Tag.Get(validateTagName) != "" || hasValidationRulesVisited(field.Type, visited) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasValidationRulesVisited(t.Elem(), visited)
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.Interface,
+		reflect.Ptr, reflect.String, reflect.UnsafePointer:
+		fallthrough
+	default:
+	}
+
+	return false
+}
+
+// applyValidationSchema documents the validation rules of a field as constraints of its OpenAPI schema
+func applyValidationSchema(schema *openapi3.Schema, fieldType reflect.Type, rules []validationRule) {
+	if schema == nil {
+		return
+	}
+
+	kind := indirectType(fieldType).Kind()
+
+	for _, rule := range rules {
+		switch rule.name {
+		case ruleMin, ruleMax, ruleLen:
+			applyBoundSchema(schema, kind, rule)
+		case ruleEnum:
+			itemsSchema := schema
+			elemType := indirectType(fieldType)
+
+			if (kind == reflect.Slice || kind == reflect.Array) && schema.Items != nil && schema.Items.Value != nil {
+				itemsSchema = schema.Items.Value
+				elemType = indirectType(elemType.Elem())
+			}
+
+			itemsSchema.Enum = nil
+
+			for _, value := range rule.enum {
+				itemsSchema.Enum = append(itemsSchema.Enum, enumValue(elemType.Kind(), value))
+			}
+		case rulePattern:
+			schema.Pattern = rule.value
+		case ruleEmail:
+			schema.Format = "email"
+		case ruleUUID:
+			schema.Format = "uuid"
+		case ruleURL:
+			schema.Format = "uri"
+		}
+	}
+}
+
+func applyBoundSchema(schema *openapi3.Schema, kind reflect.Kind, rule validationRule) {
+	number := rule.number
+	length := uint64(number)
+
+	switch {
+	case isNumberKind(kind):
+		if rule.name == ruleMin {
+			schema.Min = &number
+		} else {
+			schema.Max = &number
+		}
+	case kind == reflect.String:
+		if rule.name != ruleMax {
+			schema.MinLength = length
+		}
+
+		if rule.name != ruleMin {
+			schema.MaxLength = &length
+		}
+	case kind == reflect.Map:
+		if rule.name != ruleMax {
+			schema.MinProps = length
+		}
+
+		if rule.name != ruleMin {
+			schema.MaxProps = &length
+		}
+	default:
+		if rule.name != ruleMax {
+			schema.MinItems = length
+		}
+
+		if rule.name != ruleMin {
+			schema.MaxItems = &length
+		}
+	}
+}
+
+// applyBodyValidationSchema documents the validation rules of a request body struct in its OpenAPI schema,
+// whose properties are named by the given struct tag
+func applyBodyValidationSchema(structTag string, fieldType reflect.Type, schema *openapi3.Schema) error {
+	fieldType = indirectType(fieldType)
+
+	if schema == nil {
+		return nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < fieldType.NumField(); i++ {
+			field := fieldType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Anonymous && fieldNameFromTags(field, structTag) == field.Name {
+				if err := applyBodyValidationSchema(structTag, field.Type, schema); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			name, property := schemaProperty(schema, field, structTag)
+			if property == nil {
+				continue
+			}
+
+			rules, err := validationRules(field)
+			if err != nil {
+				return err
+			}
+
+			applyValidationSchema(property, field.Type, rules)
+
+			if hasRule(rules, ruleRequired) && !containsString(schema.Required, name) {
+				schema.Required = append(schema.Required, name)
+			}
+
+			if err := applyBodyValidationSchema(structTag, field.Type, property); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if schema.Items != nil && fieldType.Elem().Kind() != reflect.Uint8 {
+			return applyBodyValidationSchema(structTag, fieldType.Elem(), schema.Items.Value)
+		}
+	case reflect.Map:
+		if schema.AdditionalProperties.Schema != nil {
+			return applyBodyValidationSchema(structTag, fieldType.Elem(), schema.AdditionalProperties.Schema.Value)
+		}
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.Interface,
+		reflect.Ptr, reflect.String, reflect.UnsafePointer:
+		fallthrough
+	default:
+	}
+
+	return nil
+}
+
+// schemaProperty finds the property of a struct field in its schema
+func schemaProperty(schema *openapi3.Schema, field reflect.StructField, structTag string) (string, *openapi3.Schema) {
+	for _, name := range []string{fieldNameFromTags(field, structTag), fieldNameFromTags(field, "json"), field.Name} {
+		if property, ok := schema.Properties[name]; ok && property != nil {
+			return name, property.Value
+		}
+	}
+
+	return "", nil
+}
+
+// fieldNameFromTags returns the name of a body field, as declared by the struct tag of the body content type
+func fieldNameFromTags(field reflect.StructField, structTag string) string {
+	for _, tagName := range []string{structTag, "json"} {
+		name := structTagName(field, tagName)
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// paramLocation returns where a request field comes from and its name
+func paramLocation(tagMap map[string]string) (string, string) {
+	for _, in := range []string{"path", "query", "header", "cookie"} {
+		if name, ok := tagMap[in]; ok {
+			return in, name
+		}
+	}
+
+	return "body", "body"
+}
+
+// bodyContentType returns the content type declared on a body field: `lite:"req=body,application/xml"`.
+// The keys are sorted so that the content type is the same on every call when several are declared.
+func bodyContentType(tagMap map[string]string) string {
+	keys := make([]string, 0, len(tagMap))
+
+	for key := range tagMap {
+		if strings.Contains(key, "/") {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return "application/json"
+	}
+
+	sort.Strings(keys)
+
+	return keys[0]
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func hasRule(rules []validationRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumValue(kind reflect.Kind, value string) any {
+	switch {
+	case kind == reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case isNumberKind(kind):
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+
+	return value
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Invalid, reflect.Bool, reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Array,
+		reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.String,
+		reflect.Struct, reflect.UnsafePointer:
+		fallthrough
+	default:
+		return false
+	}
+}
+
+func hasLength(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+func numberOf(val reflect.Value) float64 {
+	switch {
+	case val.CanInt():
+		return float64(val.Int())
+	case val.CanUint():
+		return float64(val.Uint())
+	default:
+		return val.Float()
+	}
+}
+
+func lengthOf(val reflect.Value) int {
+	if val.Kind() == reflect.String {
+		return utf8.RuneCountInString(val.String())
+	}
+
+	return val.Len()
+}
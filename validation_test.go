@@ -0,0 +1,297 @@
+package lite
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-lite/lite/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValidationTag(t *testing.T) {
+	rules, err := parseValidationTag(reflect.TypeOf(""), "required,min=2,max=10,pattern=^[a-z]{2,10}$")
+	assert.NoError(t, err)
+	assert.Len(t, rules, 4)
+	assert.Equal(t, "^[a-z]{2,10}$", rules[3].value)
+	assert.NotNil(t, rules[3].pattern)
+
+	rules, err = parseValidationTag(reflect.TypeOf([]string{}), "enum=a|b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, rules[0].enum)
+}
+
+func TestParseValidationTag_Error(t *testing.T) {
+	tests := []struct {
+		fieldType reflect.Type
+		tag       string
+	}{
+		{reflect.TypeOf(""), "unknown"},
+		{reflect.TypeOf(""), "min=abc"},
+		{reflect.TypeOf(true), "max=1"},
+		{reflect.TypeOf(1), "len=1"},
+		{reflect.TypeOf(1), "email"},
+		{reflect.TypeOf(1), "pattern=^a$"},
+		{reflect.TypeOf(""), "pattern=("},
+		{reflect.TypeOf(""), "enum="},
+	}
+
+	for _, test := range tests {
+		_, err := parseValidationTag(test.fieldType, test.tag)
+		assert.Error(t, err, test.tag)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		value    any
+		tag      string
+		messages []string
+	}{
+		{"", "required", []string{"is required"}},
+		{(*string)(nil), "required", []string{"is required"}},
+		{(*string)(nil), "min=2", nil},
+		{"", "min=2", []string{"length must be at least 2"}},
+		{0, "min=1", []string{"must be greater than or equal to 1"}},
+		{str("a"), "min=2", []string{"length must be at least 2"}},
+		{"abc", "min=2,max=3", nil},
+		{"abcd", "max=3", []string{"length must be at most 3"}},
+		{"héllo", "len=5", nil},
+		{5, "min=10", []string{"must be greater than or equal to 10"}},
+		{uint(50), "max=10", []string{"must be less than or equal to 10"}},
+		{2.5, "min=1,max=3", nil},
+		{[]int{1}, "min=2", []string{"length must be at least 2"}},
+		{"c", "enum=a|b", []string{"must be one of [a, b]"}},
+		{[]string{"a", "c"}, "enum=a|b", []string{"must be one of [a, b]"}},
+		{[]string{"a", "b"}, "enum=a|b", nil},
+		{2, "enum=1|2", nil},
+		{"ABC", "pattern=^[a-z]+$", []string{"must match pattern ^[a-z]+$"}},
+		{"john@example.com", "email", nil},
+		{"John <john@example.com>", "email", []string{"must be a valid email address"}},
+		{"2f7b1860-9c3b-4b8e-8a3f-1d2e3f4a5b6c", "uuid", nil},
+		{"not-a-uuid", "uuid", []string{"must be a valid UUID"}},
+		{"https://example.com/path", "url", nil},
+		{"example.com", "url", []string{"must be a valid URL"}},
+	}
+
+	for _, test := range tests {
+		val := reflect.ValueOf(test.value)
+
+		rules, err := parseValidationTag(val.Type(), test.tag)
+		assert.NoError(t, err)
+		assert.Equal(t, test.messages, checkRules(val, true, rules), "%v %s", test.value, test.tag)
+	}
+
+	rules, err := parseValidationTag(reflect.TypeOf(0), "min=1")
+	assert.NoError(t, err)
+	assert.Nil(t, checkRules(reflect.ValueOf(0), false, rules))
+
+	rules, err = parseValidationTag(reflect.TypeOf(0), "required")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"is required"}, checkRules(reflect.ValueOf(0), false, rules))
+}
+
+type validationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validationBody struct {
+	Name      string              `json:"name"                validate:"required,max=5"`
+	Email     *string             `json:"email,omitempty"     validate:"email"`
+	Role      string              `json:"role"                validate:"enum=admin|user"`
+	Addresses []validationAddress `json:"addresses"           validate:"max=2"`
+	Address   *validationAddress  `json:"address,omitempty"`
+}
+
+type validationRequest struct {
+	ID     string         `lite:"path=id"       validate:"uuid"`
+	Limit  int            `lite:"query=limit"   validate:"min=1,max=100"`
+	Tenant string         `lite:"header=Tenant" validate:"required"`
+	Body   validationBody `lite:"req=body"`
+}
+
+type validationResponse struct {
+	Name string `json:"name"`
+}
+
+func newValidationApp() *App {
+	app := New()
+
+	Post(app, "/users/:id", func(c *ContextWithRequest[validationRequest]) (validationResponse, error) {
+		req, err := c.Requests()
+		if err != nil {
+			return validationResponse{}, err
+		}
+
+		return validationResponse{Name: req.Body.Name}, nil
+	})
+
+	return app
+}
+
+func TestValidation_Valid(t *testing.T) {
+	app := newValidationApp()
+
+	body := `{"name":"john","email":"john@example.com","role":"admin","addresses":[{"city":"Paris"}]}`
+	req := httptest.NewRequest("POST", "/users/2f7b1860-9c3b-4b8e-8a3f-1d2e3f4a5b6c?limit=10", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Tenant", "acme")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestValidation_Invalid(t *testing.T) {
+	app := newValidationApp()
+
+	body := `{"name":"johnny","email":"nope","role":"root","addresses":[{"city":"Paris"},{"city":""},{"city":"Rome"}],` +
+		`"address":{}}`
+	req := httptest.NewRequest("POST", "/users/123?limit=1000", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var httpError errors.HTTPError
+
+	assert.NoError(t, json.Unmarshal(data, &httpError))
+	assert.Equal(t, 422, httpError.Status)
+	assert.Equal(t, []errors.FieldError{
+		{Field: "id", In: "path", Message: "must be a valid UUID"},
+		{Field: "limit", In: "query", Message: "must be less than or equal to 100"},
+		{Field: "Tenant", In: "header", Message: "is required"},
+		{Field: "name", In: "body", Message: "length must be at most 5"},
+		{Field: "email", In: "body", Message: "must be a valid email address"},
+		{Field: "role", In: "body", Message: "must be one of [admin, user]"},
+		{Field: "addresses", In: "body", Message: "length must be at most 2"},
+		{Field: "addresses[1].city", In: "body", Message: "is required"},
+		{Field: "address.city", In: "body", Message: "is required"},
+	}, httpError.Errors)
+}
+
+func TestValidation_ZeroParam(t *testing.T) {
+	app := newValidationApp()
+
+	body := `{"name":"john","role":"user"}`
+
+	for query, status := range map[string]int{"": 201, "?limit=0": 422} {
+		req := httptest.NewRequest("POST", "/users/2f7b1860-9c3b-4b8e-8a3f-1d2e3f4a5b6c"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Tenant", "acme")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, query)
+	}
+}
+
+type pageRequest struct {
+	Limit int `lite:"query=limit" validate:"min=5,max=10"`
+}
+
+func TestValidation_OpenAPI_SharedParamName(t *testing.T) {
+	app := newValidationApp()
+
+	Get(app, "/pages", func(c *ContextWithRequest[pageRequest]) (string, error) {
+		return "", nil
+	})
+
+	users := app.OpenAPISpec.Paths.Find("/users/{id}").Post.Parameters.GetByInAndName("query", "limit")
+	pages := app.OpenAPISpec.Paths.Find("/pages").Get.Parameters.GetByInAndName("query", "limit")
+
+	assert.Equal(t, 1.0, *users.Schema.Value.Min)
+	assert.Equal(t, 100.0, *users.Schema.Value.Max)
+	assert.Equal(t, 5.0, *pages.Schema.Value.Min)
+	assert.Equal(t, 10.0, *pages.Schema.Value.Max)
+}
+
+func TestValidation_InvalidParam(t *testing.T) {
+	app := newValidationApp()
+
+	tests := map[string]errors.FieldError{
+		"?limit=abc":                  {Field: "limit", In: "query", Message: "must be an integer"},
+		"?limit=99999999999999999999": {Field: "limit", In: "query", Message: "is out of range"},
+	}
+
+	for query, fieldError := range tests {
+		req := httptest.NewRequest("POST", "/users/2f7b1860-9c3b-4b8e-8a3f-1d2e3f4a5b6c"+query, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Tenant", "acme")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode, query)
+
+		data, _ := io.ReadAll(resp.Body)
+
+		var httpError errors.HTTPError
+
+		assert.NoError(t, json.Unmarshal(data, &httpError))
+		assert.Equal(t, []errors.FieldError{fieldError}, httpError.Errors, query)
+	}
+}
+
+func TestBodyContentType(t *testing.T) {
+	for range 10 {
+		assert.Equal(t, "application/json", bodyContentType(parseTag("req=body,application/xml,application/json")))
+	}
+
+	assert.Equal(t, "application/json", bodyContentType(parseTag("req=body")))
+}
+
+func TestValidation_OpenAPI(t *testing.T) {
+	app := newValidationApp()
+
+	operation := app.OpenAPISpec.Paths.Find("/users/{id}").Post
+
+	assert.NotNil(t, operation.Responses.Value("422"))
+
+	limit := operation.Parameters.GetByInAndName("query", "limit")
+
+	assert.Equal(t, "uuid", operation.Parameters.GetByInAndName("path", "id").Schema.Value.Format)
+	assert.Equal(t, 1.0, *limit.Schema.Value.Min)
+	assert.Equal(t, 100.0, *limit.Schema.Value.Max)
+	assert.True(t, limit.Required)
+
+	body := app.OpenAPISpec.Components.Schemas["validationBody"].Value
+
+	assert.Contains(t, body.Required, "name")
+	assert.Equal(t, uint64(5), *body.Properties["name"].Value.MaxLength)
+	assert.Equal(t, "email", body.Properties["email"].Value.Format)
+	assert.Equal(t, []any{"admin", "user"}, body.Properties["role"].Value.Enum)
+	assert.Equal(t, uint64(2), *body.Properties["addresses"].Value.MaxItems)
+	assert.Contains(t, body.Properties["addresses"].Value.Items.Value.Required, "city")
+}
+
+func TestValidation_OpenAPI_NoRules(t *testing.T) {
+	app := New()
+
+	Get(app, "/foo/:id", func(c *ContextWithRequest[requestPath]) (responsePath, error) {
+		return responsePath{}, nil
+	})
+
+	assert.Nil(t, app.OpenAPISpec.Paths.Find("/foo/{id}").Get.Responses.Value("422"))
+}
+
+type invalidValidationRequest struct {
+	Limit int `lite:"query=limit" validate:"pattern=^a$"`
+}
+
+func TestValidation_InvalidRules(t *testing.T) {
+	app := New()
+
+	assert.Panics(t, func() {
+		Get(app, "/foo", func(c *ContextWithRequest[invalidValidationRequest]) (responsePath, error) {
+			return responsePath{}, nil
+		})
+	})
+}
@@ -0,0 +1,668 @@
+package lite
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" /* #nosec G505 */
+	"encoding/base64"
+	"encoding/binary"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-lite/lite/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Close codes of WebSocket connections (RFC 6455 section 7.4.1)
+const (
+	CloseNormalClosure       = 1000
+	CloseGoingAway           = 1001
+	CloseProtocolError       = 1002
+	CloseUnsupportedData     = 1003
+	CloseNoStatusReceived    = 1005
+	CloseInvalidPayload      = 1007
+	ClosePolicyViolation     = 1008
+	CloseMessageTooBig       = 1009
+	CloseInternalServerError = 1011
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// opcodes of the WebSocket frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+var (
+	// websocketPingInterval is the interval of the pings keeping the connections alive.
+	// A connection which receives nothing, not even a pong, for two intervals is closed.
+	websocketPingInterval = 30 * time.Second
+
+	// websocketWriteTimeout is the deadline of the writes of a frame
+	websocketWriteTimeout = 10 * time.Second
+
+	// websocketCloseTimeout is the deadline of the writes of a close frame
+	websocketCloseTimeout = time.Second
+
+	// websocketMaxMessageSize is the size of the largest message received, larger ones close the connection
+	websocketMaxMessageSize = 1 << 20
+)
+
+// CloseError is the close frame ending a WebSocket connection.
+// WebSocketConn.Receive returns it when the client closes the connection, and the connection is closed
+// with its code and reason when a WebSocket handler returns it.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+// RegisterSubprotocol lets the WebSocket clients select the codec of mediaType with the subprotocol name,
+// sent in the Sec-WebSocket-Protocol header. The json and xml subprotocols are registered by default,
+// and JSON is used when the client selects none.
+func (s *App) RegisterSubprotocol(name, mediaType string) *App {
+	s.subprotocols[name] = mediaType
+
+	return s
+}
+
+// negotiateSubprotocol returns the first subprotocol requested by the client with a codec,
+// and the media type of the messages
+func (s *App) negotiateSubprotocol(requested string) (string, string) {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+
+		mediaType, ok := s.subprotocols[name]
+		if !ok {
+			continue
+		}
+
+		if _, ok := s.codecs.lookup(mediaType); ok {
+			return name, mediaType
+		}
+	}
+
+	return "", fiber.MIMEApplicationJSON
+}
+
+// WebSocket registers a GET route upgrading its requests to WebSocket connections,
+// whose handler receives messages of type In and sends messages of type Out.
+// The messages are encoded with the codec of the subprotocol selected by the client (see App.RegisterSubprotocol).
+// The request is decoded and validated before the upgrade, so that its errors are written as those of the other routes.
+// The connection is closed with 1000 Normal Closure when handler returns nil, with the code of a returned CloseError,
+// with 1011 Internal Server Error otherwise, and with 1001 Going Away when the App shuts down.
+// The route is documented in the OpenAPI spec with the x-websocket extension.
+//
+//	lite.WebSocket(app, "/rooms/:id", func(conn *lite.WebSocketConn[ChatMessage, ChatEvent], req RoomRequest) error {
+//		for {
+//			message, err := conn.Receive()
+//			if err != nil {
+//				return err
+//			}
+//
+//			if err := conn.Send(ChatEvent{Room: req.ID, Text: message.Text}); err != nil {
+//				return err
+//			}
+//		}
+//	})
+func WebSocket[In, Out, Request any](
+	router Router,
+	path string,
+	handler func(conn *WebSocketConn[In, Out], req Request) error,
+	middleware ...fiber.Handler,
+) Route[Out, Request] {
+	path = router.options().prefix + path
+	app := router.app()
+	registered := &registeredRoute{}
+
+	route := registerRoute[Out, Request](
+		router,
+		Route[Out, Request]{
+			app:         app,
+			registered:  registered,
+			path:        path,
+			method:      http.MethodGet,
+			contentType: fiber.MIMEApplicationJSON,
+			statusCode:  http.StatusSwitchingProtocols,
+		},
+		websocketHandler(app, handler, path, registered),
+		middleware...,
+	)
+
+	if err := documentWebSocket[In, Out](app, route.operation); err != nil {
+		slog.ErrorContext(context.Background(), "failed to register openapi operation", slog.Any("error", err))
+		panic(err)
+	}
+
+	return route
+}
+
+// documentWebSocket documents the subprotocols and the schemas of the messages of a WebSocket route
+func documentWebSocket[In, Out any](app *App, operation *openapi3.Operation) error {
+	operation.Responses.Value("101").Value.WithDescription("Switching Protocols")
+
+	extension := map[string]any{}
+
+	for key, body := range map[string]reflect.Type{
+		"receive": reflect.TypeOf((*In)(nil)).Elem(),
+		"send":    reflect.TypeOf((*Out)(nil)).Elem(),
+	} {
+		content, err := newResponseContent(app, []string{fiber.MIMEApplicationJSON}, reflect.New(body).Interface(), body)
+		if err != nil {
+			return err
+		}
+
+		if media := content.Get(fiber.MIMEApplicationJSON); media != nil {
+			extension[key] = media.Schema
+		}
+	}
+
+	subprotocols := make([]string, 0, len(app.subprotocols))
+	for name := range app.subprotocols {
+		subprotocols = append(subprotocols, name)
+	}
+
+	sort.Strings(subprotocols)
+
+	extension["subprotocols"] = subprotocols
+
+	if operation.Extensions == nil {
+		operation.Extensions = make(map[string]any)
+	}
+
+	operation.Extensions["x-websocket"] = extension
+
+	return nil
+}
+
+func websocketHandler[In, Out, Request any](
+	app *App,
+	handler func(conn *WebSocketConn[In, Out], req Request) error,
+	path string,
+	route *registeredRoute,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := route.authenticate(c, app); err != nil {
+			return writeError(c, app, err)
+		}
+
+		key := c.Get(HeaderSecWebSocketKey)
+
+		if !headerContains(c.Get(HeaderConnection), "upgrade") || !strings.EqualFold(c.Get(HeaderUpgrade), "websocket") ||
+			key == "" {
+			return writeError(c, app, errors.NewBadRequestError("expected a WebSocket upgrade request"))
+		}
+
+		if c.Get(HeaderSecWebSocketVersion) != "13" {
+			c.Set(HeaderSecWebSocketVersion, "13")
+
+			return writeError(c, app, errors.NewError(http.StatusUpgradeRequired, "unsupported WebSocket version"))
+		}
+
+		ctx := &ContextWithRequest[Request]{
+			ContextNoRequest: ContextNoRequest{ctx: c, app: app, path: path, binder: route.binder},
+		}
+
+		req, err := ctx.Requests()
+		if err != nil {
+			return writeError(c, app, err)
+		}
+
+		subprotocol, mediaType := app.negotiateSubprotocol(c.Get(HeaderSecWebSocketProtocol))
+		codec, _ := app.codecs.lookup(mediaType)
+
+		// the request context must not be used once the connection is hijacked
+		userContext := c.UserContext()
+
+		c.Status(http.StatusSwitchingProtocols)
+		c.Set(HeaderUpgrade, "websocket")
+		c.Set(HeaderConnection, "Upgrade")
+		c.Set(HeaderSecWebSocketAccept, websocketAccept(key))
+
+		if subprotocol != "" {
+			c.Set(HeaderSecWebSocketProtocol, subprotocol)
+		}
+
+		c.Context().Hijack(func(netConn net.Conn) {
+			conn := &WebSocketConn[In, Out]{
+				conn:        newWSConn(userContext, netConn),
+				subprotocol: subprotocol,
+				mediaType:   mediaType,
+				codec:       codec,
+			}
+
+			app.websockets.add(conn.conn)
+			defer app.websockets.remove(conn.conn)
+
+			err := handler(conn, req)
+			if err != nil && !stderrors.As(err, new(*CloseError)) && conn.conn.ctx.Err() == nil {
+				slog.ErrorContext(conn.Context(), "websocket handler failed", slog.Any("error", err))
+			}
+
+			conn.conn.finish(err)
+		})
+
+		return nil
+	}
+}
+
+func headerContains(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept header of the key sent by the client
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID)) /* #nosec G401 */
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WebSocketConn is a WebSocket connection receiving messages of type In and sending messages of type Out.
+// Send and Close are safe for concurrent use, Receive must be called by one goroutine at a time.
+type WebSocketConn[In, Out any] struct {
+	conn        *wsConn
+	subprotocol string
+	mediaType   string
+	codec       Codec
+
+	// requests carrying the messages through the codec
+	receiveCtx fasthttp.RequestCtx
+	sendMu     sync.Mutex
+	sendCtx    fasthttp.RequestCtx
+}
+
+// Context returns the context of the connection, canceled once it is closed
+func (c *WebSocketConn[In, Out]) Context() context.Context {
+	return c.conn.ctx
+}
+
+// Subprotocol returns the subprotocol selected by the client, empty when it selected none
+func (c *WebSocketConn[In, Out]) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Receive waits for the next message of the client. It returns a *CloseError once the client closed the connection.
+// The connection stays open when the message cannot be decoded.
+func (c *WebSocketConn[In, Out]) Receive() (In, error) {
+	var in In
+
+	payload, err := c.conn.readMessage()
+	if err != nil {
+		return in, err
+	}
+
+	c.receiveCtx.Request.Reset()
+	c.receiveCtx.Request.Header.SetContentType(c.mediaType)
+	c.receiveCtx.Request.SetBodyRaw(payload)
+
+	if err := c.codec.Decode(&c.receiveCtx, &in); err != nil {
+		return in, err
+	}
+
+	return in, nil
+}
+
+// Send sends a message to the client, in a text frame when its media type is textual, in a binary frame otherwise
+func (c *WebSocketConn[In, Out]) Send(out Out) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.sendCtx.Response.Reset()
+
+	if err := c.codec.Encode(&c.sendCtx, out); err != nil {
+		return err
+	}
+
+	if isTextMediaType(c.mediaType) {
+		return c.conn.writeFrame(opText, c.sendCtx.Response.Body())
+	}
+
+	return c.conn.writeFrame(opBinary, c.sendCtx.Response.Body())
+}
+
+// Close closes the connection with a close code, e.g. CloseNormalClosure, and a reason
+func (c *WebSocketConn[In, Out]) Close(code int, reason string) error {
+	return c.conn.close(code, reason)
+}
+
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml")
+}
+
+// wsConn reads and writes the frames of a WebSocket connection (RFC 6455)
+type wsConn struct {
+	conn         net.Conn
+	reader       *bufio.Reader
+	parent       context.Context // context of the request, cancelled when the App shuts down
+	ctx          context.Context
+	cancel       context.CancelFunc
+	pingInterval time.Duration
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func newWSConn(ctx context.Context, conn net.Conn) *wsConn {
+	connCtx, cancel := context.WithCancel(ctx)
+
+	c := &wsConn{
+		conn:         conn,
+		reader:       bufio.NewReader(conn),
+		parent:       ctx,
+		ctx:          connCtx,
+		cancel:       cancel,
+		pingInterval: websocketPingInterval,
+	}
+
+	go c.keepAlive()
+
+	return c
+}
+
+// readMessage returns the payload of the next message, answering the control frames received meanwhile
+func (c *wsConn) readMessage() ([]byte, error) {
+	var (
+		message []byte
+		opcode  byte
+	)
+
+	for {
+		if err := c.conn.SetReadDeadline(time.Now().Add(2 * c.pingInterval)); err != nil {
+			return nil, c.fail(err)
+		}
+
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, c.fail(err)
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, c.fail(err)
+			}
+
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: CloseNoStatusReceived}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+
+			_ = c.close(closeErr.Code, "")
+
+			return nil, closeErr
+		case opContinuation:
+			if opcode == 0 {
+				return nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: "unexpected continuation frame"})
+			}
+		case opText, opBinary:
+			if opcode != 0 {
+				return nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: "expected a continuation frame"})
+			}
+
+			opcode = op
+		default:
+			return nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: "unknown opcode"})
+		}
+
+		if len(message)+len(payload) > websocketMaxMessageSize {
+			return nil, c.fail(&CloseError{Code: CloseMessageTooBig, Reason: "message too big"})
+		}
+
+		message = append(message, payload...)
+
+		if !fin {
+			continue
+		}
+
+		if opcode == opText && !utf8.Valid(message) {
+			return nil, c.fail(&CloseError{Code: CloseInvalidPayload, Reason: "invalid UTF-8"})
+		}
+
+		return message, nil
+	}
+}
+
+// readFrame reads a frame sent by the client, whose payload is masked
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0f
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "unsupported extension"}
+	}
+
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "unmasked frame"}
+	}
+
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if op >= opClose && (!fin || length > 125) {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "invalid control frame"}
+	}
+
+	if length > uint64(websocketMaxMessageSize) {
+		return false, 0, nil, &CloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, op, payload, nil
+}
+
+// writeFrame writes an unfragmented and unmasked frame
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|op)
+
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	frame = append(frame, payload...)
+
+	// the close frame is sent even once the context is cancelled, e.g. when the App shuts down,
+	// without waiting for a client which does not read anymore
+	timeout := websocketWriteTimeout
+	if op == opClose {
+		timeout = websocketCloseTimeout
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+// close sends a close frame, once, and closes the connection
+func (c *wsConn) close(code int, reason string) error {
+	var err error
+
+	c.closeOnce.Do(func() {
+		var payload []byte
+
+		if code != CloseNoStatusReceived {
+			payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+			payload = append(payload, reason...)
+		}
+
+		err = c.writeFrame(opClose, payload)
+
+		c.cancel()
+		_ = c.conn.Close()
+	})
+
+	return err
+}
+
+// fail closes the connection after a read error, with the code of a protocol error
+func (c *wsConn) fail(err error) error {
+	var closeErr *CloseError
+	if stderrors.As(err, &closeErr) {
+		_ = c.close(closeErr.Code, closeErr.Reason)
+
+		return err
+	}
+
+	c.cancel()
+	_ = c.conn.Close()
+
+	return err
+}
+
+// finish closes the connection once its handler returned err, with 1001 Going Away when the App shuts down
+func (c *wsConn) finish(err error) {
+	var closeErr *CloseError
+
+	switch {
+	case stderrors.As(err, &closeErr):
+		_ = c.close(closeErr.Code, closeErr.Reason)
+	case c.parent.Err() != nil:
+		_ = c.close(CloseGoingAway, "server shutting down")
+	case err == nil:
+		_ = c.close(CloseNormalClosure, "")
+	default:
+		_ = c.close(CloseInternalServerError, "")
+	}
+}
+
+// keepAlive pings the client on every tick, until the connection is closed
+func (c *wsConn) keepAlive() {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(opPing, nil); err != nil {
+				_ = c.fail(err)
+
+				return
+			}
+		}
+	}
+}
+
+// websocketRegistry tracks the open WebSocket connections of an App, closed when it shuts down
+type websocketRegistry struct {
+	mu    sync.Mutex
+	conns map[*wsConn]struct{}
+}
+
+func (r *websocketRegistry) add(conn *wsConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conns == nil {
+		r.conns = make(map[*wsConn]struct{})
+	}
+
+	r.conns[conn] = struct{}{}
+}
+
+func (r *websocketRegistry) remove(conn *wsConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.conns, conn)
+}
+
+// goingAway closes the open connections with 1001 Going Away
+func (r *websocketRegistry) goingAway() {
+	r.mu.Lock()
+	conns := make([]*wsConn, 0, len(r.conns))
+
+	for conn := range r.conns {
+		conns = append(conns, conn)
+	}
+	r.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.close(CloseGoingAway, "server shutting down")
+	}
+}
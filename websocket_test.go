@@ -0,0 +1,323 @@
+package lite
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type chatMessage struct {
+	Text string `json:"text" xml:"text"`
+}
+
+type chatEvent struct {
+	Room string `json:"room" xml:"room"`
+	Text string `json:"text" xml:"text"`
+}
+
+type roomRequest struct {
+	ID string `lite:"path=id"`
+}
+
+func echoHandler(conn *WebSocketConn[chatMessage, chatEvent], req roomRequest) error {
+	for {
+		message, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		if message.Text == "bye" {
+			return &CloseError{Code: ClosePolicyViolation, Reason: "bye"}
+		}
+
+		if err := conn.Send(chatEvent{Room: req.ID, Text: message.Text}); err != nil {
+			return err
+		}
+	}
+}
+
+// serveWebSocket serves the App on a local port and returns its address
+func serveWebSocket(t *testing.T, app *App) string {
+	t.Helper()
+
+	app.OpenAPIConfig.DisableLocalSave = true
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	t.Cleanup(func() {
+		_ = app.Shutdown()
+	})
+
+	return ln.Addr().String()
+}
+
+type websocketClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, addr, path, protocol string) (*websocketClient, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := "GET " + path + " HTTP/1.1\r\nHost: " + addr + "\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"
+	if protocol != "" {
+		request += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+
+	_, err = conn.Write([]byte(request + "\r\n"))
+	require.NoError(t, err)
+
+	client := &websocketClient{conn: conn, reader: bufio.NewReader(conn)}
+
+	resp, err := http.ReadResponse(client.reader, nil)
+	require.NoError(t, err)
+
+	return client, resp
+}
+
+func (c *websocketClient) write(t *testing.T, op byte, payload string) {
+	t.Helper()
+
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | op, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+
+	_, err := c.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func (c *websocketClient) read(t *testing.T) (byte, string) {
+	t.Helper()
+
+	var header [2]byte
+
+	_, err := io.ReadFull(c.reader, header[:])
+	require.NoError(t, err)
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var extended [2]byte
+
+		_, err = io.ReadFull(c.reader, extended[:])
+		require.NoError(t, err)
+
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+
+	payload := make([]byte, length)
+
+	_, err = io.ReadFull(c.reader, payload)
+	require.NoError(t, err)
+
+	return header[0] & 0x0f, string(payload)
+}
+
+func closePayload(code int, reason string) string {
+	return string(binary.BigEndian.AppendUint16(nil, uint16(code))) + reason
+}
+
+func TestWebSocket(t *testing.T) {
+	app := New()
+
+	WebSocket(app, "/rooms/:id", echoHandler)
+
+	client, resp := dialWebSocket(t, serveWebSocket(t, app), "/rooms/42", "")
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get(HeaderSecWebSocketAccept))
+	assert.Empty(t, resp.Header.Get(HeaderSecWebSocketProtocol))
+
+	client.write(t, opText, `{"text":"hello"}`)
+	op, payload := client.read(t)
+	assert.Equal(t, byte(opText), op)
+	assert.JSONEq(t, `{"room":"42","text":"hello"}`, payload)
+
+	client.write(t, opPing, "ping")
+	op, payload = client.read(t)
+	assert.Equal(t, byte(opPong), op)
+	assert.Equal(t, "ping", payload)
+
+	client.write(t, opClose, closePayload(CloseNormalClosure, "done"))
+	op, payload = client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseNormalClosure, ""), payload)
+
+	operation := app.OpenAPISpec.Paths.Find("/rooms/{id}").Get
+	assert.NotNil(t, operation.Responses.Value("101"))
+	extension, ok := operation.Extensions["x-websocket"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, []string{"json", "xml"}, extension["subprotocols"])
+	assert.NotNil(t, extension["receive"])
+	assert.NotNil(t, extension["send"])
+}
+
+func TestWebSocket_Subprotocol(t *testing.T) {
+	app := New()
+
+	WebSocket(app, "/rooms/:id", echoHandler)
+
+	client, resp := dialWebSocket(t, serveWebSocket(t, app), "/rooms/42", "graphql-ws, xml")
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "xml", resp.Header.Get(HeaderSecWebSocketProtocol))
+
+	client.write(t, opText, `<chatMessage><text>hello</text></chatMessage>`)
+	_, payload := client.read(t)
+	assert.Equal(t, `<chatEvent><room>42</room><text>hello</text></chatEvent>`, payload)
+}
+
+func TestWebSocket_Close(t *testing.T) {
+	app := New()
+
+	WebSocket(app, "/rooms/:id", echoHandler)
+
+	addr := serveWebSocket(t, app)
+
+	client, _ := dialWebSocket(t, addr, "/rooms/42", "")
+	client.write(t, opText, `{"text":"bye"}`)
+	op, payload := client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(ClosePolicyViolation, "bye"), payload)
+
+	client, _ = dialWebSocket(t, addr, "/rooms/42", "")
+	client.write(t, opText, `{"text":`)
+	op, payload = client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseInternalServerError, ""), payload)
+
+	client, _ = dialWebSocket(t, addr, "/rooms/42", "")
+	client.write(t, opText, "{\"text\":\"\xff\"}")
+	op, payload = client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseInvalidPayload, "invalid UTF-8"), payload)
+
+	client, _ = dialWebSocket(t, addr, "/rooms/42", "")
+	_, err := client.conn.Write([]byte{0x80 | opText, 5, 'h', 'e', 'l', 'l', 'o'})
+	require.NoError(t, err)
+	op, payload = client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseProtocolError, "unmasked frame"), payload)
+}
+
+func TestWebSocket_Shutdown(t *testing.T) {
+	app := New()
+
+	connected := make(chan struct{})
+
+	WebSocket(app, "/events", func(conn *WebSocketConn[chatMessage, chatEvent], _ struct{}) error {
+		close(connected)
+
+		<-conn.Context().Done()
+
+		return nil
+	})
+
+	client, _ := dialWebSocket(t, serveWebSocket(t, app), "/events", "")
+	<-connected
+
+	require.NoError(t, app.Shutdown())
+
+	op, payload := client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseGoingAway, "server shutting down"), payload)
+}
+
+// the close frame is sent although the deadline of the shutdown cancelled the context of the connection
+func TestWebSocket_ShutdownDeadline(t *testing.T) {
+	app := New()
+
+	connected := make(chan struct{})
+
+	WebSocket(app, "/events", func(conn *WebSocketConn[chatMessage, chatEvent], _ struct{}) error {
+		close(connected)
+
+		<-conn.Context().Done()
+
+		return nil
+	})
+
+	client, _ := dialWebSocket(t, serveWebSocket(t, app), "/events", "")
+	<-connected
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_ = app.ShutdownWithContext(ctx)
+
+	op, payload := client.read(t)
+	assert.Equal(t, byte(opClose), op)
+	assert.Equal(t, closePayload(CloseGoingAway, "server shutting down"), payload)
+}
+
+func TestWebSocket_KeepAlive(t *testing.T) {
+	defaultPingInterval := websocketPingInterval
+	websocketPingInterval = 10 * time.Millisecond
+
+	defer func() {
+		websocketPingInterval = defaultPingInterval
+	}()
+
+	app := New()
+
+	WebSocket(app, "/rooms/:id", echoHandler)
+
+	client, _ := dialWebSocket(t, serveWebSocket(t, app), "/rooms/42", "")
+
+	op, _ := client.read(t)
+	assert.Equal(t, byte(opPing), op)
+}
+
+func TestWebSocket_BadRequest(t *testing.T) {
+	app := New()
+
+	WebSocket(app, "/rooms/:id", echoHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/rooms/42", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req := httptest.NewRequest("GET", "/rooms/42", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get(HeaderSecWebSocketVersion))
+}
+
+func TestCloseError(t *testing.T) {
+	assert.EqualError(t, &CloseError{Code: CloseGoingAway}, "websocket closed with code 1001")
+	assert.EqualError(t, &CloseError{Code: ClosePolicyViolation, Reason: "bye"}, fmt.Sprintf("websocket closed with code %d: bye", 1008))
+}